@@ -0,0 +1,194 @@
+package f1viz
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"image"
+	"sync"
+
+	"go.viam.com/rdk/components/camera"
+	"go.viam.com/rdk/data"
+	"go.viam.com/rdk/logging"
+	"go.viam.com/rdk/pointcloud"
+	"go.viam.com/rdk/resource"
+	"go.viam.com/rdk/rimage"
+	generic "go.viam.com/rdk/services/generic"
+	"go.viam.com/rdk/spatialmath"
+	rutils "go.viam.com/rdk/utils"
+)
+
+// F1vizCamera renders the replay as camera images
+var F1vizCamera = resource.NewModel("vijayvuyyuru", "viz", "f1viz-camera")
+
+func init() {
+	resource.RegisterComponent(camera.API, F1vizCamera,
+		resource.Registration[camera.Camera, *CameraConfig]{
+			Constructor: newVizCamera,
+		},
+	)
+}
+
+// CameraConfig configures an f1viz-camera
+type CameraConfig struct {
+	// Name of the f1viz service whose replay is shown. It must run in the same module.
+	Service string `json:"service"`
+	// Size of images in pixels. Both default to 800.
+	Width  int `json:"width,omitempty"`
+	Height int `json:"height,omitempty"`
+}
+
+// Validate requires the f1viz service, which is the camera's only dependency
+func (cfg *CameraConfig) Validate(path string) ([]string, []string, error) {
+	if cfg.Service == "" {
+		return nil, nil, resource.NewConfigValidationFieldRequiredError(path, "service")
+	}
+	if cfg.Width < 0 || cfg.Height < 0 {
+		return nil, nil, fmt.Errorf("%s: width and height must not be negative", path)
+	}
+	return []string{cfg.Service}, nil, nil
+}
+
+// width returns the configured image width
+func (cfg *CameraConfig) width() int {
+	if cfg.Width <= 0 {
+		return defaultImageSize
+	}
+	return cfg.Width
+}
+
+// height returns the configured image height
+func (cfg *CameraConfig) height() int {
+	if cfg.Height <= 0 {
+		return defaultImageSize
+	}
+	return cfg.Height
+}
+
+// frameRenderer keeps the latest frame as an image for the camera
+type frameRenderer struct {
+	mu     sync.Mutex
+	canvas *trackCanvas
+	frame  Frame
+}
+
+// DrawTrack draws the track into the canvas
+func (f *frameRenderer) DrawTrack(ctx context.Context, scene TrackScene) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.canvas.drawTrack(scene)
+	return nil
+}
+
+// DrawFrame remembers the frame; it is only rasterised when an image is requested
+func (f *frameRenderer) DrawFrame(ctx context.Context, frame Frame) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.frame = frame
+	return nil
+}
+
+// Clear forgets the drivers
+func (f *frameRenderer) Clear(ctx context.Context) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.frame = Frame{}
+	return nil
+}
+
+// Close does nothing; the camera keeps showing the last frame
+func (f *frameRenderer) Close(ctx context.Context) error {
+	return nil
+}
+
+// image returns the latest frame drawn over the track
+func (f *frameRenderer) image() *image.NRGBA {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return f.canvas.drawFrame(f.frame)
+}
+
+// vizCamera is a camera showing the replay of an f1viz service, which it forwards DoCommand to
+type vizCamera struct {
+	resource.AlwaysRebuild
+
+	name    resource.Name
+	service resource.Resource
+	local   *vizF1viz // The service itself, which draws into frames
+	frames  *frameRenderer
+}
+
+func newVizCamera(ctx context.Context, deps resource.Dependencies, rawConf resource.Config, logger logging.Logger) (camera.Camera, error) {
+	conf, err := resource.NativeConfig[*CameraConfig](rawConf)
+	if err != nil {
+		return nil, err
+	}
+	service, err := generic.FromProvider(deps, conf.Service)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get f1viz service %q: %w", conf.Service, err)
+	}
+	// The dependency is a client, so find the service itself to draw its frames
+	local, ok := localService(service.Name().ShortName())
+	if !ok {
+		return nil, fmt.Errorf("f1viz service %q must run in the same module as the camera", conf.Service)
+	}
+
+	frames := &frameRenderer{canvas: newTrackCanvas(conf.width(), conf.height())}
+	if err := local.addRenderer(ctx, frames); err != nil {
+		logger.Warnf("Failed to draw reference track: %v", err)
+	}
+	return &vizCamera{name: rawConf.ResourceName(), service: service, local: local, frames: frames}, nil
+}
+
+func (c *vizCamera) Name() resource.Name {
+	return c.name
+}
+
+// Image returns the latest frame encoded as mimeType, PNG by default
+func (c *vizCamera) Image(ctx context.Context, mimeType string, extra map[string]interface{}) ([]byte, camera.ImageMetadata, error) {
+	if mimeType == "" {
+		mimeType = rutils.MimeTypePNG
+	}
+	mimeType, _ = rutils.CheckLazyMIMEType(mimeType)
+	img, err := rimage.EncodeImage(ctx, c.frames.image(), mimeType)
+	if err != nil {
+		return nil, camera.ImageMetadata{}, err
+	}
+	return img, camera.ImageMetadata{MimeType: mimeType}, nil
+}
+
+// Images returns the latest frame
+func (c *vizCamera) Images(ctx context.Context, filterSourceNames []string, extra map[string]interface{}) ([]camera.NamedImage, resource.ResponseMetadata, error) {
+	img, err := camera.NamedImageFromImage(c.frames.image(), c.name.ShortName(), rutils.MimeTypePNG, data.Annotations{})
+	if err != nil {
+		return nil, resource.ResponseMetadata{}, err
+	}
+	return []camera.NamedImage{img}, resource.ResponseMetadata{}, nil
+}
+
+// NextPointCloud is not supported
+func (c *vizCamera) NextPointCloud(ctx context.Context, extra map[string]interface{}) (pointcloud.PointCloud, error) {
+	return nil, errors.New("f1viz camera does not support point clouds")
+}
+
+func (c *vizCamera) Properties(ctx context.Context) (camera.Properties, error) {
+	return camera.Properties{
+		ImageType: camera.ColorStream,
+		MimeTypes: []string{rutils.MimeTypePNG, rutils.MimeTypeJPEG},
+	}, nil
+}
+
+func (c *vizCamera) Geometries(ctx context.Context, extra map[string]interface{}) ([]spatialmath.Geometry, error) {
+	return nil, nil
+}
+
+// DoCommand accepts the same commands as the f1viz service, such as start and stop
+func (c *vizCamera) DoCommand(ctx context.Context, cmd map[string]interface{}) (map[string]interface{}, error) {
+	return c.service.DoCommand(ctx, cmd)
+}
+
+// Close stops the service drawing into the camera
+func (c *vizCamera) Close(ctx context.Context) error {
+	c.local.removeRenderer(c.frames)
+	return nil
+}
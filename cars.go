@@ -0,0 +1,137 @@
+package f1viz
+
+import (
+	"fmt"
+	"image/color"
+	"math"
+
+	"github.com/golang/geo/r3"
+	vizClient "github.com/viam-labs/motion-tools/client/client"
+	"go.viam.com/rdk/referenceframe"
+	"go.viam.com/rdk/spatialmath"
+)
+
+const (
+	carStyleBox  = "box"
+	carStyleNone = "none"
+
+	// Size of a car in OpenF1 units (roughly decimetres)
+	carLength = 56.0
+	carWidth  = 20.0
+	carHeight = 10.0
+	// defaultCarScale draws cars larger than life so they stay visible next to a whole circuit
+	defaultCarScale = 3.0
+	// minHeadingDistance is how far a car must move, in OpenF1 units, before its heading is updated,
+	// so noise while stationary doesn't spin it around
+	minHeadingDistance = 5.0
+)
+
+// validateCarStyle checks a car style
+func validateCarStyle(style string) error {
+	switch style {
+	case carStyleBox, carStyleNone:
+		return nil
+	default:
+		return fmt.Errorf("car_style must be %q or %q, got %q", carStyleBox, carStyleNone, style)
+	}
+}
+
+// carStyle returns the configured car style, defaulting to boxes
+func (cfg *Config) carStyle() string {
+	if cfg == nil || cfg.CarStyle == "" {
+		return carStyleBox
+	}
+	return cfg.CarStyle
+}
+
+// carScale returns how many times life size cars are drawn
+func (cfg *Config) carScale() float64 {
+	if cfg == nil || cfg.CarScale <= 0 {
+		return defaultCarScale
+	}
+	return cfg.CarScale
+}
+
+// headingBetween returns the direction of travel from one location to the next in degrees,
+// counter-clockwise from the X axis, and false if the car has barely moved
+func headingBetween(from, to Location) (float64, bool) {
+	dx, dy := float64(to.X-from.X), float64(to.Y-from.Y)
+	if math.Hypot(dx, dy) < minHeadingDistance {
+		return 0, false
+	}
+	return math.Atan2(dy, dx) * 180 / math.Pi, true
+}
+
+// drawCars draws every driver as a box in their car colour pointing in their direction of travel, and removes
+// the boxes of drivers no longer in the frame
+func (r *motionToolsRenderer) drawCars(frame Frame, transform sceneTransform) error {
+	scale := r.cfg.carScale() * transform.scale
+	dims := r3.Vector{X: carLength * scale, Y: carWidth * scale, Z: carHeight * scale}
+
+	labels := make(map[string]bool, len(frame.Drivers))
+	geometries := make([]spatialmath.Geometry, 0, len(frame.Drivers))
+	colors := make([]string, 0, len(frame.Drivers))
+	for _, driver := range frame.Drivers {
+		label := carLabel(driver.DriverNumber)
+		center := transform.apply(r3.Vector{
+			X: float64(driver.Location.X),
+			Y: float64(driver.Location.Y),
+			Z: float64(driver.Location.Z),
+		})
+		// Rest the box on the track rather than half under it
+		center.Z += dims.Z / 2
+		orientation := &spatialmath.OrientationVectorDegrees{OZ: 1, Theta: transform.heading(driver.Heading)}
+		box, err := spatialmath.NewBox(spatialmath.NewPose(center, orientation), dims, label)
+		if err != nil {
+			return err
+		}
+		geometries = append(geometries, box)
+		colors = append(colors, hexColor(carColor(frame, driver.DriverNumber)))
+		labels[label] = true
+	}
+
+	var stale []string
+	for label := range r.cars {
+		if !labels[label] {
+			stale = append(stale, label)
+		}
+	}
+	if len(stale) > 0 {
+		if err := vizClient.RemoveSpatialObjects(stale); err != nil {
+			return err
+		}
+	}
+	r.cars = labels
+
+	if len(geometries) == 0 {
+		return nil
+	}
+	return vizClient.DrawGeometries(referenceframe.NewGeometriesInFrame(referenceframe.World, geometries), colors)
+}
+
+// carColor returns the colour of a driver's car: the colour of the newest point of their trail, so cars
+// follow the trail colour mode, or the driver's colour if they have no trail
+func carColor(frame Frame, driverNumber int) color.NRGBA {
+	if colors := frame.TrailColors[driverNumber]; len(colors) > 0 {
+		return colors[len(colors)-1]
+	}
+	return frame.Colors[driverNumber]
+}
+
+// clearCars removes every drawn car
+func (r *motionToolsRenderer) clearCars() error {
+	if len(r.cars) == 0 {
+		return nil
+	}
+	labels := make([]string, 0, len(r.cars))
+	for label := range r.cars {
+		labels = append(labels, label)
+	}
+	r.cars = nil
+	return vizClient.RemoveSpatialObjects(labels)
+}
+
+// carLabel is the visualizer label of a driver's car
+func carLabel(driverNumber int) string {
+	return fmt.Sprintf("car-%d", driverNumber)
+}
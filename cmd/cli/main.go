@@ -3,6 +3,14 @@ package main
 import (
 	"context"
 	"f1viz"
+	"flag"
+	"fmt"
+	"os"
+	"os/signal"
+	"strconv"
+	"strings"
+	"time"
+
 	"go.viam.com/rdk/logging"
 	"go.viam.com/rdk/resource"
 	generic "go.viam.com/rdk/services/generic"
@@ -24,11 +32,225 @@ func realMain() error {
 
 	cfg := f1viz.Config{}
 
+	if len(os.Args) > 1 {
+		switch os.Args[1] {
+		case "watch":
+			return watchCommand(ctx, deps, &cfg, logger, os.Args[2:])
+		case "record":
+			return recordCommand(ctx, deps, &cfg, logger, os.Args[2:])
+		}
+	}
+
 	thing, err := f1viz.NewF1viz(ctx, deps, generic.Named("foo"), &cfg, logger)
 	if err != nil {
 		return err
 	}
 	defer thing.Close(ctx)
 
+	if len(os.Args) > 1 {
+		switch os.Args[1] {
+		case "export":
+			return exportCommand(ctx, thing, os.Args[2:])
+		default:
+			return fmt.Errorf("unknown subcommand %q", os.Args[1])
+		}
+	}
+
+	return nil
+}
+
+// watchCommand replays a session in the terminal until it ends, the duration passes or it is interrupted
+func watchCommand(ctx context.Context, deps resource.Dependencies, cfg *f1viz.Config, logger logging.Logger, args []string) error {
+	fs := flag.NewFlagSet("watch", flag.ContinueOnError)
+	drivers := fs.String("drivers", "", "comma separated driver numbers to follow")
+	top := fs.Int("top", 0, "follow the top N drivers by race position instead of -drivers")
+	width := fs.Int("width", 100, "width of the map in characters")
+	height := fs.Int("height", 40, "height of the map in characters")
+	duration := fs.Duration("duration", 0, "how long to watch for; 0 watches until interrupted")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+
+	var start interface{}
+	if *top > 0 {
+		start = map[string]interface{}{"top": *top}
+	} else {
+		driverNumbers, err := parseDriverNumbers(*drivers)
+		if err != nil {
+			return err
+		}
+		if len(driverNumbers) == 0 {
+			return fmt.Errorf("watch requires -drivers or -top")
+		}
+		start = driverNumbers
+	}
+
+	// Log messages would scroll the map away
+	logger.SetLevel(logging.ERROR)
+
+	terminal := newTerminalRenderer(os.Stdout, *width, *height)
+	thing, err := f1viz.NewF1vizWithRenderers(ctx, deps, generic.Named("foo"), cfg, logger, []f1viz.Renderer{terminal})
+	if err != nil {
+		return err
+	}
+	defer thing.Close(ctx)
+
+	ctx, stop := signal.NotifyContext(ctx, os.Interrupt)
+	defer stop()
+	if *duration > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, *duration)
+		defer cancel()
+	}
+
+	if _, err := thing.DoCommand(ctx, map[string]interface{}{"start": start}); err != nil {
+		return err
+	}
+	<-ctx.Done()
+	return nil
+}
+
+// recordCommand replays part of a session headless, writing it as a PNG sequence or an animated GIF
+func recordCommand(ctx context.Context, deps resource.Dependencies, cfg *f1viz.Config, logger logging.Logger, args []string) error {
+	fs := flag.NewFlagSet("record", flag.ContinueOnError)
+	drivers := fs.String("drivers", "", "comma separated driver numbers to record")
+	top := fs.Int("top", 0, "record the top N drivers by race position instead of -drivers")
+	session := fs.Int("session", 0, "OpenF1 session key; defaults to the configured session")
+	from := fs.String("from", "", "where to start: an RFC3339 time or a duration after the session start such as 45m")
+	to := fs.String("to", "", "where to stop, in the same form as -from")
+	format := fs.String("format", f1viz.ImageFormatGIF, "png for a numbered PNG sequence or gif for an animated GIF")
+	out := fs.String("out", "record", "directory to write frames to")
+	width := fs.Int("width", 800, "image width in pixels")
+	height := fs.Int("height", 800, "image height in pixels")
+	interval := fs.Duration("interval", 200*time.Millisecond, "playback time between frames")
+	speed := fs.Float64("speed", 1, "how much faster than real time the GIF plays")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	if *to == "" {
+		return fmt.Errorf("record requires -to so the replay ends")
+	}
+
+	start := map[string]interface{}{"from": *from, "to": *to}
+	if *session > 0 {
+		start["session_key"] = *session
+	}
+	if *top > 0 {
+		start["top"] = *top
+	} else {
+		driverNumbers, err := parseDriverNumbers(*drivers)
+		if err != nil {
+			return err
+		}
+		if len(driverNumbers) == 0 {
+			return fmt.Errorf("record requires -drivers or -top")
+		}
+		start["drivers"] = driverNumbers
+	}
+
+	renderer, err := f1viz.NewImageRenderer(f1viz.ImageRendererOptions{
+		Dir:      *out,
+		Format:   *format,
+		Width:    *width,
+		Height:   *height,
+		Interval: *interval,
+		Speed:    *speed,
+	})
+	if err != nil {
+		return err
+	}
+	thing, err := f1viz.NewF1vizWithRenderers(ctx, deps, generic.Named("foo"), cfg, logger, []f1viz.Renderer{renderer})
+	if err != nil {
+		return err
+	}
+	// Closing the service closes the renderer, which writes the GIF
+	defer thing.Close(ctx)
+
+	ctx, stop := signal.NotifyContext(ctx, os.Interrupt)
+	defer stop()
+
+	if _, err := thing.DoCommand(ctx, map[string]interface{}{"start": start}); err != nil {
+		return err
+	}
+
+	// Wait for the replay to reach the end of the range
+	ticker := time.NewTicker(500 * time.Millisecond)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-ticker.C:
+		}
+		state, err := thing.DoCommand(ctx, map[string]interface{}{"get_state": true})
+		if err != nil {
+			return err
+		}
+		if running, _ := state["running"].(bool); !running {
+			logger.Infof("Recorded %s to %s", *format, *out)
+			return nil
+		}
+	}
+}
+
+// parseDriverNumbers parses a comma separated list of driver numbers
+func parseDriverNumbers(s string) ([]interface{}, error) {
+	var driverNumbers []interface{}
+	for _, field := range strings.Split(s, ",") {
+		if field = strings.TrimSpace(field); field == "" {
+			continue
+		}
+		n, err := strconv.Atoi(field)
+		if err != nil {
+			return nil, fmt.Errorf("invalid driver number %q: %w", field, err)
+		}
+		driverNumbers = append(driverNumbers, n)
+	}
+	return driverNumbers, nil
+}
+
+// exportCommand writes the reference track, and optionally driver paths from a replay, to disk
+func exportCommand(ctx context.Context, thing resource.Resource, args []string) error {
+	fs := flag.NewFlagSet("export", flag.ContinueOnError)
+	out := fs.String("out", "export", "directory to write files to")
+	formats := fs.String("formats", "svg,geojson,csv", "comma separated formats to write")
+	drivers := fs.String("drivers", "", "comma separated driver numbers to replay and export paths for")
+	duration := fs.Duration("duration", 30*time.Second, "how long to replay before exporting driver paths")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+
+	driverNumbers, err := parseDriverNumbers(*drivers)
+	if err != nil {
+		return err
+	}
+
+	if len(driverNumbers) > 0 {
+		if _, err := thing.DoCommand(ctx, map[string]interface{}{"start": driverNumbers}); err != nil {
+			return err
+		}
+		time.Sleep(*duration)
+		if _, err := thing.DoCommand(ctx, map[string]interface{}{"stop": true}); err != nil {
+			return err
+		}
+	}
+
+	var formatList []interface{}
+	for _, format := range strings.Split(*formats, ",") {
+		if format = strings.TrimSpace(format); format != "" {
+			formatList = append(formatList, format)
+		}
+	}
+	resp, err := thing.DoCommand(ctx, map[string]interface{}{
+		"export": map[string]interface{}{
+			"dir":     *out,
+			"formats": formatList,
+			"drivers": driverNumbers,
+		},
+	})
+	if err != nil {
+		return err
+	}
+	fmt.Println(resp["files"])
 	return nil
 }
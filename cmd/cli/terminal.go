@@ -0,0 +1,204 @@
+package main
+
+import (
+	"context"
+	"f1viz"
+	"fmt"
+	"image/color"
+	"io"
+	"math"
+	"strings"
+	"time"
+)
+
+const (
+	// terminalFrameInterval is the shortest time between redraws, to keep output manageable over SSH
+	terminalFrameInterval = 100 * time.Millisecond
+	// cellAspect is how much taller a terminal cell is than it is wide
+	cellAspect = 2.0
+)
+
+var pitLaneColor = color.NRGBA{R: 128, G: 128, B: 128, A: 255}
+
+// cell is one character of the terminal map
+type cell struct {
+	ch    rune
+	color color.NRGBA
+}
+
+// terminalRenderer rasterises the track and cars into the terminal with ANSI colours
+type terminalRenderer struct {
+	out           io.Writer
+	width, height int
+
+	// Track drawn into a grid of height rows by width columns, and how OpenF1 X/Y map onto it
+	track             []cell
+	minX, maxY, scale float64
+	offsetX, offsetY  float64
+
+	started  bool
+	lastDraw time.Time
+}
+
+// newTerminalRenderer returns a renderer that draws a width by height character map to out
+func newTerminalRenderer(out io.Writer, width, height int) *terminalRenderer {
+	return &terminalRenderer{out: out, width: width, height: height}
+}
+
+// cellAt returns the column and row for an OpenF1 X/Y
+func (t *terminalRenderer) cellAt(x, y int) (int, int) {
+	col := t.offsetX + (float64(x)-t.minX)*t.scale
+	row := t.offsetY + (t.maxY-float64(y))*t.scale/cellAspect
+	return int(math.Round(col)), int(math.Round(row))
+}
+
+// set writes a character into a grid if it is on the map
+func (t *terminalRenderer) set(grid []cell, col, row int, ch rune, c color.NRGBA) {
+	if col < 0 || col >= t.width || row < 0 || row >= t.height {
+		return
+	}
+	grid[row*t.width+col] = cell{ch: ch, color: c}
+}
+
+// line draws a straight line of characters between two OpenF1 points
+func (t *terminalRenderer) line(grid []cell, a, b f1viz.TrackPoint, ch rune, c color.NRGBA) {
+	c0, r0 := t.cellAt(a.X, a.Y)
+	c1, r1 := t.cellAt(b.X, b.Y)
+	steps := max(abs(c1-c0), abs(r1-r0), 1)
+	for i := 0; i <= steps; i++ {
+		f := float64(i) / float64(steps)
+		t.set(grid, int(math.Round(float64(c0)+f*float64(c1-c0))), int(math.Round(float64(r0)+f*float64(r1-r0))), ch, c)
+	}
+}
+
+// DrawTrack fits the track to the terminal and rasterises it
+func (t *terminalRenderer) DrawTrack(ctx context.Context, scene f1viz.TrackScene) error {
+	points := scene.Track.Points
+	t.minX, t.maxY = math.MaxFloat64, -math.MaxFloat64
+	maxX, minY := -math.MaxFloat64, math.MaxFloat64
+	for _, p := range points {
+		t.minX, maxX = math.Min(t.minX, float64(p.X)), math.Max(maxX, float64(p.X))
+		minY, t.maxY = math.Min(minY, float64(p.Y)), math.Max(t.maxY, float64(p.Y))
+	}
+	spanX, spanY := math.Max(maxX-t.minX, 1), math.Max(t.maxY-minY, 1)
+	t.scale = math.Min(float64(t.width-1)/spanX, float64(t.height-1)*cellAspect/spanY)
+	t.offsetX = (float64(t.width-1) - spanX*t.scale) / 2
+	t.offsetY = (float64(t.height-1) - spanY*t.scale/cellAspect) / 2
+
+	t.track = make([]cell, t.width*t.height)
+	if pit := scene.Track.PitLane; pit != nil {
+		for i := 1; i < len(pit.Points); i++ {
+			t.line(t.track, pit.Points[i-1], pit.Points[i], '.', pitLaneColor)
+		}
+	}
+	for i := range points {
+		next := points[(i+1)%len(points)]
+		t.line(t.track, points[i], next, '#', scene.Colors[i])
+	}
+	return t.draw(nil)
+}
+
+// DrawFrame draws every driver's marker and acronym over the track, leaders on top
+func (t *terminalRenderer) DrawFrame(ctx context.Context, frame f1viz.Frame) error {
+	if t.track == nil || time.Since(t.lastDraw) < terminalFrameInterval {
+		return nil
+	}
+	return t.draw(&frame)
+}
+
+// Clear redraws the track without any drivers
+func (t *terminalRenderer) Clear(ctx context.Context) error {
+	if t.track == nil {
+		return nil
+	}
+	return t.draw(nil)
+}
+
+// Close resets the terminal's colours and cursor
+func (t *terminalRenderer) Close(ctx context.Context) error {
+	if !t.started {
+		return nil
+	}
+	_, err := fmt.Fprint(t.out, "\x1b[0m\x1b[?25h\n")
+	return err
+}
+
+// draw writes the track, and the frame's drivers if there is one, to the terminal
+func (t *terminalRenderer) draw(frame *f1viz.Frame) error {
+	grid := append([]cell(nil), t.track...)
+	var header string
+	if frame != nil {
+		for i := len(frame.Drivers) - 1; i >= 0; i-- {
+			driver := frame.Drivers[i]
+			c := frame.Colors[driver.DriverNumber]
+			col, row := t.cellAt(driver.Location.X, driver.Location.Y)
+			t.set(grid, col, row, '●', c)
+			for j, ch := range frame.Acronyms[driver.DriverNumber] {
+				t.set(grid, col+1+j, row, ch, c)
+			}
+		}
+		header = t.header(*frame)
+	}
+
+	var b strings.Builder
+	if !t.started {
+		// Clear the screen and hide the cursor
+		b.WriteString("\x1b[2J\x1b[?25l")
+		t.started = true
+	}
+	b.WriteString("\x1b[H\x1b[0m")
+	b.WriteString(header)
+	b.WriteString("\x1b[K\n")
+
+	var current color.NRGBA
+	for row := 0; row < t.height; row++ {
+		for col := 0; col < t.width; col++ {
+			cell := grid[row*t.width+col]
+			if cell.ch == 0 {
+				b.WriteByte(' ')
+				continue
+			}
+			if cell.color != current {
+				fmt.Fprintf(&b, "\x1b[38;2;%d;%d;%dm", cell.color.R, cell.color.G, cell.color.B)
+				current = cell.color
+			}
+			b.WriteRune(cell.ch)
+		}
+		b.WriteString("\x1b[K\n")
+	}
+	b.WriteString("\x1b[0m")
+
+	t.lastDraw = time.Now()
+	_, err := io.WriteString(t.out, b.String())
+	return err
+}
+
+// header returns the lap, playback time and running order, cut to the width of the map
+func (t *terminalRenderer) header(frame f1viz.Frame) string {
+	var b strings.Builder
+	if len(frame.Drivers) > 0 {
+		fmt.Fprintf(&b, "Lap %d  ", frame.Drivers[0].Lap)
+	}
+	if !frame.PlaybackTime.IsZero() {
+		fmt.Fprintf(&b, "%s  ", frame.PlaybackTime.Format("15:04:05"))
+	}
+	for i, driver := range frame.Drivers {
+		position := driver.Position
+		if position == 0 {
+			position = i + 1
+		}
+		entry := fmt.Sprintf("%d.%s ", position, frame.Acronyms[driver.DriverNumber])
+		if b.Len()+len(entry) > t.width {
+			break
+		}
+		b.WriteString(entry)
+	}
+	return b.String()
+}
+
+func abs(n int) int {
+	if n < 0 {
+		return -n
+	}
+	return n
+}
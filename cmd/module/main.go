@@ -2,6 +2,8 @@ package main
 
 import (
 	"f1viz"
+	"go.viam.com/rdk/components/camera"
+	"go.viam.com/rdk/components/sensor"
 	"go.viam.com/rdk/module"
 	"go.viam.com/rdk/resource"
 	generic "go.viam.com/rdk/services/generic"
@@ -9,5 +11,9 @@ import (
 
 func main() {
 	// ModularMain can take multiple APIModel arguments, if your module implements multiple models.
-	module.ModularMain(resource.APIModel{ generic.API, f1viz.F1viz})
+	module.ModularMain(
+		resource.APIModel{API: generic.API, Model: f1viz.F1viz},
+		resource.APIModel{API: camera.API, Model: f1viz.F1vizCamera},
+		resource.APIModel{API: sensor.API, Model: f1viz.F1vizSensor},
+	)
 }
@@ -0,0 +1,231 @@
+package f1viz
+
+import (
+	"context"
+	_ "embed"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"sync"
+	"time"
+
+	"go.viam.com/rdk/logging"
+)
+
+const (
+	// defaultDashboardAddress is where the dashboard listens when it is enabled without an address
+	defaultDashboardAddress = ":8090"
+	// dashboardClientBuffer is how many events a slow browser may fall behind before events are dropped for it
+	dashboardClientBuffer = 16
+)
+
+//go:embed dashboard/index.html
+var dashboardPage []byte
+
+// dashboardTrack is the track as sent to browsers
+type dashboardTrack struct {
+	Points  [][2]int `json:"points"`
+	Colors  []string `json:"colors"`
+	PitLane [][2]int `json:"pit_lane,omitempty"`
+	Sectors []int    `json:"sectors"` // Track indices where each sector starts
+}
+
+// dashboardDriver is one driver in a frame as sent to browsers
+type dashboardDriver struct {
+	DriverNumber    int     `json:"driver_number"`
+	Acronym         string  `json:"acronym"`
+	Color           string  `json:"color"`
+	X               int     `json:"x"`
+	Y               int     `json:"y"`
+	Position        int     `json:"position"`
+	Lap             int     `json:"lap"`
+	GapToLeaderLaps float64 `json:"gap_to_leader_laps"`
+	InPitLane       bool    `json:"in_pit_lane"`
+}
+
+// dashboardFrame is a frame as sent to browsers
+type dashboardFrame struct {
+	PlaybackTime string            `json:"playback_time"`
+	Flags        FlagState         `json:"flags"`
+	Drivers      []dashboardDriver `json:"drivers"` // In running order
+}
+
+// dashboardRenderer serves a track map web page and streams frames to it with server-sent events
+type dashboardRenderer struct {
+	logger   logging.Logger
+	server   *http.Server
+	listener net.Listener
+
+	mu        sync.Mutex
+	clients   map[chan []byte]struct{}
+	lastTrack []byte // Latest events, sent to browsers as soon as they connect
+	lastFrame []byte
+}
+
+// newDashboardRenderer starts serving the dashboard on address
+func newDashboardRenderer(address string, logger logging.Logger) (*dashboardRenderer, error) {
+	listener, err := net.Listen("tcp", address)
+	if err != nil {
+		return nil, fmt.Errorf("failed to listen on %s: %w", address, err)
+	}
+
+	d := &dashboardRenderer{
+		logger:   logger,
+		listener: listener,
+		clients:  make(map[chan []byte]struct{}),
+	}
+	mux := http.NewServeMux()
+	mux.HandleFunc("/", d.serveIndex)
+	mux.HandleFunc("/events", d.serveEvents)
+	d.server = &http.Server{Handler: mux, ReadHeaderTimeout: 10 * time.Second}
+
+	go func() {
+		if err := d.server.Serve(listener); err != nil && !errors.Is(err, http.ErrServerClosed) {
+			logger.Errorf("Dashboard server stopped: %v", err)
+		}
+	}()
+	logger.Infof("Serving dashboard on %s", listener.Addr())
+	return d, nil
+}
+
+// serveIndex serves the single page dashboard
+func (d *dashboardRenderer) serveIndex(w http.ResponseWriter, r *http.Request) {
+	if r.URL.Path != "/" {
+		http.NotFound(w, r)
+		return
+	}
+	w.Header().Set("Content-Type", "text/html; charset=utf-8")
+	w.Write(dashboardPage)
+}
+
+// serveEvents streams track and frame events to a browser until it disconnects
+func (d *dashboardRenderer) serveEvents(w http.ResponseWriter, r *http.Request) {
+	flusher, ok := w.(http.Flusher)
+	if !ok {
+		http.Error(w, "streaming unsupported", http.StatusInternalServerError)
+		return
+	}
+	w.Header().Set("Content-Type", "text/event-stream")
+	w.Header().Set("Cache-Control", "no-cache")
+	w.Header().Set("Connection", "keep-alive")
+
+	events := make(chan []byte, dashboardClientBuffer)
+	d.mu.Lock()
+	d.clients[events] = struct{}{}
+	for _, event := range [][]byte{d.lastTrack, d.lastFrame} {
+		if event != nil {
+			events <- event
+		}
+	}
+	d.mu.Unlock()
+	defer func() {
+		d.mu.Lock()
+		delete(d.clients, events)
+		d.mu.Unlock()
+	}()
+
+	for {
+		select {
+		case <-r.Context().Done():
+			return
+		case event, ok := <-events:
+			if !ok {
+				return
+			}
+			if _, err := w.Write(event); err != nil {
+				return
+			}
+			flusher.Flush()
+		}
+	}
+}
+
+// broadcast sends an event to every connected browser, dropping it for browsers that have fallen behind
+func (d *dashboardRenderer) broadcast(name string, v interface{}) error {
+	data, err := json.Marshal(v)
+	if err != nil {
+		return err
+	}
+	event := []byte(fmt.Sprintf("event: %s\ndata: %s\n\n", name, data))
+
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	switch name {
+	case "track":
+		d.lastTrack = event
+	case "frame":
+		d.lastFrame = event
+	}
+	for client := range d.clients {
+		select {
+		case client <- event:
+		default:
+		}
+	}
+	return nil
+}
+
+// DrawTrack sends the track to every browser
+func (d *dashboardRenderer) DrawTrack(ctx context.Context, scene TrackScene) error {
+	track := scene.Track
+	out := dashboardTrack{
+		Points: make([][2]int, len(track.Points)),
+		Colors: make([]string, len(track.Points)),
+	}
+	for i, p := range track.Points {
+		out.Points[i] = [2]int{p.X, p.Y}
+		out.Colors[i] = hexColor(scene.Colors[i])
+	}
+	if track.PitLane != nil {
+		for _, p := range track.PitLane.Points {
+			out.PitLane = append(out.PitLane, [2]int{p.X, p.Y})
+		}
+	}
+	out.Sectors, _ = track.sectorLayout(len(track.Points))
+	return d.broadcast("track", out)
+}
+
+// DrawFrame sends every driver's position and the leaderboard to every browser
+func (d *dashboardRenderer) DrawFrame(ctx context.Context, frame Frame) error {
+	out := dashboardFrame{
+		Flags:   frame.Flags,
+		Drivers: make([]dashboardDriver, 0, len(frame.Drivers)),
+	}
+	if !frame.PlaybackTime.IsZero() {
+		out.PlaybackTime = frame.PlaybackTime.UTC().Format(time.RFC3339Nano)
+	}
+	for _, driver := range frame.Drivers {
+		out.Drivers = append(out.Drivers, dashboardDriver{
+			DriverNumber:    driver.DriverNumber,
+			Acronym:         frame.Acronyms[driver.DriverNumber],
+			Color:           hexColor(frame.Colors[driver.DriverNumber]),
+			X:               driver.Location.X,
+			Y:               driver.Location.Y,
+			Position:        driver.Position,
+			Lap:             driver.Lap,
+			GapToLeaderLaps: driver.GapToLeaderLaps,
+			InPitLane:       driver.InPitLane,
+		})
+	}
+	return d.broadcast("frame", out)
+}
+
+// Clear sends an empty frame so browsers remove the drivers
+func (d *dashboardRenderer) Clear(ctx context.Context) error {
+	return d.broadcast("frame", dashboardFrame{Flags: FlagState{Track: trackFlagGreen}, Drivers: []dashboardDriver{}})
+}
+
+// Close stops the server and disconnects every browser
+func (d *dashboardRenderer) Close(ctx context.Context) error {
+	ctx, cancel := context.WithTimeout(ctx, 5*time.Second)
+	defer cancel()
+	d.mu.Lock()
+	for client := range d.clients {
+		close(client)
+	}
+	d.clients = make(map[chan []byte]struct{})
+	d.mu.Unlock()
+	return d.server.Shutdown(ctx)
+}
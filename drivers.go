@@ -0,0 +1,53 @@
+package f1viz
+
+import (
+	"context"
+	"image/color"
+	"strconv"
+)
+
+// loadDrivers fetches the name and team colour of every driver in a session. Drivers without a valid
+// team colour fall back to the default palette.
+func (s *vizF1viz) loadDrivers(ctx context.Context, sessionKey int) error {
+	drivers, err := fetchDrivers(ctx, sessionKey)
+	if err != nil {
+		return err
+	}
+	info := make(map[int]Driver, len(drivers))
+	colors := make(map[int]color.NRGBA, len(drivers))
+	for _, driver := range drivers {
+		info[driver.DriverNumber] = driver
+		c, err := parseTeamColour(driver.TeamColour)
+		if err != nil {
+			s.logger.Debugf("Driver %d: %v", driver.DriverNumber, err)
+			continue
+		}
+		colors[driver.DriverNumber] = c
+	}
+
+	s.stateMu.Lock()
+	defer s.stateMu.Unlock()
+	s.driverInfo = info
+	s.teamColors = colors
+	return nil
+}
+
+// teamColor returns a driver's team colour, or their palette colour if it is not known
+func (s *vizF1viz) teamColor(driverNumber int) color.NRGBA {
+	s.stateMu.RLock()
+	defer s.stateMu.RUnlock()
+	if c, ok := s.teamColors[driverNumber]; ok {
+		return c
+	}
+	return driverColor(driverNumber)
+}
+
+// driverAcronym returns a driver's three letter abbreviation, or their number if it is not known
+func (s *vizF1viz) driverAcronym(driverNumber int) string {
+	s.stateMu.RLock()
+	defer s.stateMu.RUnlock()
+	if driver, ok := s.driverInfo[driverNumber]; ok && driver.NameAcronym != "" {
+		return driver.NameAcronym
+	}
+	return strconv.Itoa(driverNumber)
+}
@@ -0,0 +1,386 @@
+package f1viz
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"image/color"
+	"math"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+const (
+	exportFormatSVG     = "svg"
+	exportFormatGeoJSON = "geojson"
+	exportFormatCSV     = "csv"
+
+	// defaultExportDir is where exports are written when no directory is given
+	defaultExportDir = "export"
+	// svgSize is the width and height of exported SVGs in pixels
+	svgSize = 1000.0
+	// svgMargin is the blank border around exported SVGs in pixels
+	svgMargin = 40.0
+	// minRecordedSpacing is the least distance between consecutive points of a recorded path, so cars
+	// standing in the pit box or behind a red flag add nothing
+	minRecordedSpacing = 50.0
+	// maxRecordedPoints is the most points kept per recorded path. A full path drops every other point,
+	// so a long race is kept at a lower resolution rather than cut short.
+	maxRecordedPoints = 10000
+)
+
+// exportRequest holds the arguments of an export command
+type exportRequest struct {
+	Dir     string   `json:"dir"`     // Defaults to "export"
+	Formats []string `json:"formats"` // Any of svg, geojson and csv; defaults to all
+	Drivers []int    `json:"drivers"` // Driver paths to include; defaults to every recorded driver
+}
+
+// recordPaths appends each driver's latest location to their recorded path, unless it is too close to
+// the last point recorded
+func (s *vizF1viz) recordPaths(currentLocations map[int]Location) {
+	s.stateMu.Lock()
+	defer s.stateMu.Unlock()
+	if s.recordedPaths == nil {
+		s.recordedPaths = make(map[int][]Location)
+	}
+	for driverNumber, location := range currentLocations {
+		path := s.recordedPaths[driverNumber]
+		if n := len(path); n > 0 && distance2D(path[n-1].X, path[n-1].Y, location.X, location.Y) < minRecordedSpacing {
+			continue
+		}
+		if len(path) >= maxRecordedPoints {
+			path = thinPath(path)
+		}
+		// Exports only use positions, so the telemetry is not kept
+		location.CarData = nil
+		s.recordedPaths[driverNumber] = append(path, location)
+	}
+}
+
+// clearRecordedPaths forgets every recorded path
+func (s *vizF1viz) clearRecordedPaths() {
+	s.stateMu.Lock()
+	defer s.stateMu.Unlock()
+	s.recordedPaths = nil
+}
+
+// thinPath drops every other point of a path in place, keeping its first point
+func thinPath(path []Location) []Location {
+	kept := path[:0]
+	for i := 0; i < len(path); i += 2 {
+		kept = append(kept, path[i])
+	}
+	return kept
+}
+
+// pathsForExport returns copies of the recorded paths of the requested drivers, or all of them
+func (s *vizF1viz) pathsForExport(drivers []int) map[int][]Location {
+	s.stateMu.RLock()
+	defer s.stateMu.RUnlock()
+
+	paths := make(map[int][]Location)
+	if len(drivers) == 0 {
+		for driverNumber := range s.recordedPaths {
+			drivers = append(drivers, driverNumber)
+		}
+	}
+	for _, driverNumber := range drivers {
+		if path, ok := s.recordedPaths[driverNumber]; ok {
+			paths[driverNumber] = append([]Location(nil), path...)
+		}
+	}
+	return paths
+}
+
+// exportCommand handles the export command, writing the reference track and recorded paths to disk
+func (s *vizF1viz) exportCommand(cmdValue interface{}) (map[string]interface{}, error) {
+	var req exportRequest
+	if err := decodeCommandArgs(cmdValue, &req); err != nil {
+		return nil, fmt.Errorf("export: %w", err)
+	}
+	if req.Dir == "" {
+		req.Dir = defaultExportDir
+	}
+	if len(req.Formats) == 0 {
+		req.Formats = []string{exportFormatSVG, exportFormatGeoJSON, exportFormatCSV}
+	}
+
+	track := s.currentReferenceTrack()
+	paths := s.pathsForExport(req.Drivers)
+	if track == nil && len(paths) == 0 {
+		return nil, fmt.Errorf("export: nothing to export, no reference track or recorded paths")
+	}
+
+	if err := os.MkdirAll(req.Dir, 0o755); err != nil {
+		return nil, fmt.Errorf("export: failed to create %s: %w", req.Dir, err)
+	}
+
+	var files []interface{}
+	for _, format := range req.Formats {
+		var written []string
+		var err error
+		switch format {
+		case exportFormatSVG:
+			written, err = exportSVG(req.Dir, track, paths)
+		case exportFormatGeoJSON:
+			written, err = exportGeoJSON(req.Dir, track, paths)
+		case exportFormatCSV:
+			written, err = exportCSV(req.Dir, track, paths)
+		default:
+			return nil, fmt.Errorf("export: unknown format %q", format)
+		}
+		if err != nil {
+			return nil, fmt.Errorf("export %s: %w", format, err)
+		}
+		for _, file := range written {
+			files = append(files, file)
+		}
+	}
+
+	s.logger.Infof("Exported %d files to %s", len(files), req.Dir)
+	return map[string]interface{}{
+		"status": "success",
+		"files":  files,
+	}, nil
+}
+
+// sortedDrivers returns the driver numbers of a path map in order
+func sortedDrivers(paths map[int][]Location) []int {
+	drivers := make([]int, 0, len(paths))
+	for driverNumber := range paths {
+		drivers = append(drivers, driverNumber)
+	}
+	sort.Ints(drivers)
+	return drivers
+}
+
+// svgProjection maps OpenF1 X/Y onto SVG pixels, preserving aspect ratio and flipping Y so north is up
+type svgProjection struct {
+	minX, maxY, scale float64
+}
+
+// newSVGProjection fits every point of the track and paths into the SVG
+func newSVGProjection(track *ReferenceTrack, paths map[int][]Location) svgProjection {
+	minX, minY := math.MaxFloat64, math.MaxFloat64
+	maxX, maxY := -math.MaxFloat64, -math.MaxFloat64
+	add := func(x, y int) {
+		minX, maxX = math.Min(minX, float64(x)), math.Max(maxX, float64(x))
+		minY, maxY = math.Min(minY, float64(y)), math.Max(maxY, float64(y))
+	}
+	if track != nil {
+		for _, p := range track.Points {
+			add(p.X, p.Y)
+		}
+	}
+	for _, path := range paths {
+		for _, loc := range path {
+			add(loc.X, loc.Y)
+		}
+	}
+	span := math.Max(maxX-minX, maxY-minY)
+	if span == 0 {
+		span = 1
+	}
+	return svgProjection{minX: minX, maxY: maxY, scale: (svgSize - 2*svgMargin) / span}
+}
+
+// point returns SVG coordinates for an OpenF1 point
+func (p svgProjection) point(x, y int) string {
+	return fmt.Sprintf("%.1f,%.1f", svgMargin+(float64(x)-p.minX)*p.scale, svgMargin+(p.maxY-float64(y))*p.scale)
+}
+
+// hexColor formats a colour for SVG
+func hexColor(c color.NRGBA) string {
+	return fmt.Sprintf("#%02x%02x%02x", c.R, c.G, c.B)
+}
+
+// exportSVG writes the track, its markers and driver paths as a single SVG
+func exportSVG(dir string, track *ReferenceTrack, paths map[int][]Location) ([]string, error) {
+	proj := newSVGProjection(track, paths)
+	var b strings.Builder
+	fmt.Fprintf(&b, "<svg xmlns=\"http://www.w3.org/2000/svg\" width=\"%.0f\" height=\"%.0f\" viewBox=\"0 0 %.0f %.0f\">\n",
+		svgSize, svgSize, svgSize, svgSize)
+	fmt.Fprintf(&b, "  <rect width=\"100%%\" height=\"100%%\" fill=\"#15151e\"/>\n")
+
+	if track != nil {
+		polyline := func(points []TrackPoint) string {
+			coords := make([]string, len(points))
+			for i, p := range points {
+				coords[i] = proj.point(p.X, p.Y)
+			}
+			return strings.Join(coords, " ")
+		}
+		fmt.Fprintf(&b, "  <polyline id=\"track\" points=\"%s\" fill=\"none\" stroke=\"#c0c0c0\" stroke-width=\"8\" stroke-linejoin=\"round\"/>\n",
+			polyline(track.closedPoints()))
+		if track.PitLane != nil {
+			fmt.Fprintf(&b, "  <polyline id=\"pit-lane\" points=\"%s\" fill=\"none\" stroke=\"#808080\" stroke-width=\"4\" stroke-dasharray=\"8 6\"/>\n",
+				polyline(track.PitLane.Points))
+		}
+
+		sectorStarts, _ := track.sectorLayout(len(track.Points))
+		for i, index := range sectorStarts {
+			p := track.Points[index]
+			xy := strings.Split(proj.point(p.X, p.Y), ",")
+			fmt.Fprintf(&b, "  <circle class=\"sector\" cx=\"%s\" cy=\"%s\" r=\"7\" fill=\"#e10600\"/>\n", xy[0], xy[1])
+			fmt.Fprintf(&b, "  <text x=\"%s\" y=\"%s\" dx=\"10\" dy=\"-10\" fill=\"#ffffff\" font-family=\"sans-serif\" font-size=\"18\">S%d</text>\n",
+				xy[0], xy[1], i+1)
+		}
+	}
+
+	for _, driverNumber := range sortedDrivers(paths) {
+		path := paths[driverNumber]
+		coords := make([]string, len(path))
+		for i, loc := range path {
+			coords[i] = proj.point(loc.X, loc.Y)
+		}
+		fmt.Fprintf(&b, "  <polyline class=\"driver\" data-driver=\"%d\" points=\"%s\" fill=\"none\" stroke=\"%s\" stroke-width=\"2\" stroke-opacity=\"0.8\"/>\n",
+			driverNumber, strings.Join(coords, " "), hexColor(driverColor(driverNumber)))
+	}
+	b.WriteString("</svg>\n")
+
+	file := filepath.Join(dir, "track.svg")
+	return []string{file}, os.WriteFile(file, []byte(b.String()), 0o644)
+}
+
+// geoJSONFeature is a GeoJSON feature with local (OpenF1) X/Y/Z coordinates rather than longitude/latitude
+type geoJSONFeature struct {
+	Type       string                 `json:"type"`
+	Geometry   map[string]interface{} `json:"geometry"`
+	Properties map[string]interface{} `json:"properties"`
+}
+
+// exportGeoJSON writes the track, its markers and driver paths as a GeoJSON-style feature collection
+func exportGeoJSON(dir string, track *ReferenceTrack, paths map[int][]Location) ([]string, error) {
+	var features []geoJSONFeature
+	lineString := func(points []TrackPoint) map[string]interface{} {
+		coords := make([][3]int, len(points))
+		for i, p := range points {
+			coords[i] = [3]int{p.X, p.Y, p.Z}
+		}
+		return map[string]interface{}{"type": "LineString", "coordinates": coords}
+	}
+
+	if track != nil {
+		features = append(features, geoJSONFeature{
+			Type:       "Feature",
+			Geometry:   lineString(track.closedPoints()),
+			Properties: map[string]interface{}{"kind": "reference_track", "circuit_key": track.CircuitKey},
+		})
+		if track.PitLane != nil {
+			features = append(features, geoJSONFeature{
+				Type:     "Feature",
+				Geometry: lineString(track.PitLane.Points),
+				Properties: map[string]interface{}{
+					"kind":        "pit_lane",
+					"entry_index": track.PitLane.EntryIndex,
+					"exit_index":  track.PitLane.ExitIndex,
+				},
+			})
+		}
+		sectorStarts, _ := track.sectorLayout(len(track.Points))
+		for i, index := range sectorStarts {
+			p := track.Points[index]
+			features = append(features, geoJSONFeature{
+				Type:       "Feature",
+				Geometry:   map[string]interface{}{"type": "Point", "coordinates": [3]int{p.X, p.Y, p.Z}},
+				Properties: map[string]interface{}{"kind": "sector_start", "sector": i + 1, "track_index": index},
+			})
+		}
+	}
+
+	for _, driverNumber := range sortedDrivers(paths) {
+		path := paths[driverNumber]
+		points := make([]TrackPoint, len(path))
+		for i, loc := range path {
+			points[i] = TrackPoint{X: loc.X, Y: loc.Y, Z: loc.Z}
+		}
+		properties := map[string]interface{}{"kind": "driver_path", "driver_number": driverNumber}
+		if len(path) > 0 {
+			properties["date_start"] = path[0].Date
+			properties["date_end"] = path[len(path)-1].Date
+		}
+		features = append(features, geoJSONFeature{Type: "Feature", Geometry: lineString(points), Properties: properties})
+	}
+
+	data, err := json.MarshalIndent(map[string]interface{}{
+		"type":     "FeatureCollection",
+		"crs":      map[string]interface{}{"type": "name", "properties": map[string]string{"name": "openf1:local"}},
+		"features": features,
+	}, "", "  ")
+	if err != nil {
+		return nil, err
+	}
+	file := filepath.Join(dir, "track.geojson")
+	return []string{file}, os.WriteFile(file, data, 0o644)
+}
+
+// writeCSV writes rows to a CSV file
+func writeCSV(file string, rows [][]string) error {
+	f, err := os.Create(file)
+	if err != nil {
+		return err
+	}
+	w := csv.NewWriter(f)
+	if err := w.WriteAll(rows); err != nil {
+		f.Close()
+		return err
+	}
+	return f.Close()
+}
+
+// exportCSV writes the track points and driver paths as CSV files
+func exportCSV(dir string, track *ReferenceTrack, paths map[int][]Location) ([]string, error) {
+	var files []string
+	itoa := strconv.Itoa
+
+	if track != nil {
+		sectorStarts, miniStarts := track.sectorLayout(len(track.Points))
+		rows := [][]string{{"index", "x", "y", "z", "sector", "mini_sector"}}
+		sector, mini := 0, 0
+		for i, p := range track.Points {
+			for sector+1 < len(sectorStarts) && i >= sectorStarts[sector+1] {
+				sector++
+			}
+			for mini+1 < len(miniStarts) && i >= miniStarts[mini+1] {
+				mini++
+			}
+			rows = append(rows, []string{itoa(i), itoa(p.X), itoa(p.Y), itoa(p.Z), itoa(sector + 1), itoa(mini + 1)})
+		}
+		file := filepath.Join(dir, "track.csv")
+		if err := writeCSV(file, rows); err != nil {
+			return nil, err
+		}
+		files = append(files, file)
+
+		if track.PitLane != nil {
+			rows := [][]string{{"index", "x", "y", "z"}}
+			for i, p := range track.PitLane.Points {
+				rows = append(rows, []string{itoa(i), itoa(p.X), itoa(p.Y), itoa(p.Z)})
+			}
+			file := filepath.Join(dir, "pit_lane.csv")
+			if err := writeCSV(file, rows); err != nil {
+				return nil, err
+			}
+			files = append(files, file)
+		}
+	}
+
+	if len(paths) > 0 {
+		rows := [][]string{{"driver_number", "date", "x", "y", "z", "track_index"}}
+		for _, driverNumber := range sortedDrivers(paths) {
+			for _, loc := range paths[driverNumber] {
+				rows = append(rows, []string{itoa(driverNumber), loc.Date, itoa(loc.X), itoa(loc.Y), itoa(loc.Z), itoa(loc.TrackIndex)})
+			}
+		}
+		file := filepath.Join(dir, "paths.csv")
+		if err := writeCSV(file, rows); err != nil {
+			return nil, err
+		}
+		files = append(files, file)
+	}
+	return files, nil
+}
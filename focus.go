@@ -0,0 +1,218 @@
+package f1viz
+
+import (
+	"fmt"
+	"math"
+	"time"
+
+	"github.com/golang/geo/r3"
+	vizClient "github.com/viam-labs/motion-tools/client/client"
+)
+
+const (
+	focusAuto = "auto"
+	focusOff  = "off"
+
+	// defaultFocusCycle is how long auto focus stays on one battle
+	defaultFocusCycle = 15 * time.Second
+	// defaultBattleGap is how close, in laps, two cars must be to count as battling (roughly 100m)
+	defaultBattleGap = 0.02
+	// unfocusedBrightness is how much of their colour drivers other than the focused one keep
+	unfocusedBrightness = 0.25
+
+	// Where the chase camera sits relative to the focused car, in track widths
+	chaseDistanceRatio = 8.0
+	chaseHeightRatio   = 4.0
+	// chaseUpdateInterval limits how often the chase camera is moved
+	chaseUpdateInterval = 100 * time.Millisecond
+)
+
+// FocusConfig controls the focus mode that highlights one driver
+type FocusConfig struct {
+	// Cycle focus between drivers who are battling for position, starting when a replay starts
+	AutoCycle bool `json:"auto_cycle,omitempty"`
+	// Seconds auto focus stays on one battle. Defaults to 15.
+	CycleSeconds float64 `json:"cycle_seconds,omitempty"`
+	// How close two cars must be, in laps, to count as battling. Defaults to 0.02.
+	BattleGap float64 `json:"battle_gap,omitempty"`
+	// Highlight the focused driver without moving the visualizer's camera
+	FixedCamera bool `json:"fixed_camera,omitempty"`
+}
+
+// validate checks the focus settings
+func (fc *FocusConfig) validate(path string) error {
+	if fc.CycleSeconds < 0 {
+		return fmt.Errorf("%s: focus.cycle_seconds must not be negative", path)
+	}
+	if fc.BattleGap < 0 {
+		return fmt.Errorf("%s: focus.battle_gap must not be negative", path)
+	}
+	return nil
+}
+
+// focusCycle returns how long auto focus stays on one battle
+func (cfg *Config) focusCycle() time.Duration {
+	if cfg == nil || cfg.Focus == nil || cfg.Focus.CycleSeconds <= 0 {
+		return defaultFocusCycle
+	}
+	return time.Duration(cfg.Focus.CycleSeconds * float64(time.Second))
+}
+
+// battleGap returns how close, in laps, two cars must be to count as battling
+func (cfg *Config) battleGap() float64 {
+	if cfg == nil || cfg.Focus == nil || cfg.Focus.BattleGap <= 0 {
+		return defaultBattleGap
+	}
+	return cfg.Focus.BattleGap
+}
+
+// chaseCamera reports whether the visualizer's camera follows the focused driver
+func (cfg *Config) chaseCamera() bool {
+	return cfg == nil || cfg.Focus == nil || !cfg.Focus.FixedCamera
+}
+
+// focusCommand handles the focus command. It takes a driver number to focus on, "auto" to cycle between
+// battling drivers, or "off" (or 0) to show every driver equally.
+func (s *vizF1viz) focusCommand(cmdValue interface{}) (map[string]interface{}, error) {
+	driverNumber, auto := 0, false
+	switch v := cmdValue.(type) {
+	case nil:
+	case string:
+		switch v {
+		case focusAuto:
+			auto = true
+		case focusOff, "":
+		default:
+			return nil, fmt.Errorf("focus expects a driver number, %q or %q, got %q", focusAuto, focusOff, v)
+		}
+	default:
+		n, err := toInt(v)
+		if err != nil {
+			return nil, fmt.Errorf("focus: %w", err)
+		}
+		if n < 0 {
+			return nil, fmt.Errorf("focus: invalid driver number %d", n)
+		}
+		driverNumber = n
+	}
+
+	s.stateMu.Lock()
+	defer s.stateMu.Unlock()
+	s.focusDriver = driverNumber
+	s.focusAuto = auto
+	s.focusSwitched = time.Time{}
+	return map[string]interface{}{
+		"status":        "success",
+		"driver_number": driverNumber,
+		"auto":          auto,
+	}, nil
+}
+
+// updateFocus returns the driver to focus on, 0 for none, moving auto focus on to the next battle when
+// the current one has been shown long enough or has ended. drivers are in running order.
+func (s *vizF1viz) updateFocus(drivers []DriverState) int {
+	s.stateMu.Lock()
+	defer s.stateMu.Unlock()
+	if !s.focusAuto {
+		return s.focusDriver
+	}
+
+	attackers := battlingDrivers(drivers, s.cfg.battleGap())
+	current := -1
+	for i, driverNumber := range attackers {
+		if driverNumber == s.focusDriver {
+			current = i
+		}
+	}
+	if current >= 0 && time.Since(s.focusSwitched) < s.cfg.focusCycle() {
+		return s.focusDriver
+	}
+
+	switch {
+	case len(attackers) > 0:
+		// Move on to the next battle down the order, wrapping around to the front
+		next := attackers[0]
+		for _, driverNumber := range attackers {
+			if positionOf(drivers, driverNumber) > positionOf(drivers, s.focusDriver) {
+				next = driverNumber
+				break
+			}
+		}
+		s.focusDriver = next
+	case positionOf(drivers, s.focusDriver) < 0 && len(drivers) > 0:
+		// No battles: stay on whoever was focused while they're still running, else follow the leader
+		s.focusDriver = drivers[0].DriverNumber
+	default:
+		return s.focusDriver
+	}
+	s.focusSwitched = time.Now()
+	return s.focusDriver
+}
+
+// battlingDrivers returns, in running order, every driver within gap laps of the car ahead
+func battlingDrivers(drivers []DriverState, gap float64) []int {
+	var attackers []int
+	for i := 1; i < len(drivers); i++ {
+		ahead, behind := drivers[i-1], drivers[i]
+		if behind.RaceDistance <= 0 {
+			continue
+		}
+		if ahead.RaceDistance-behind.RaceDistance < gap {
+			attackers = append(attackers, behind.DriverNumber)
+		}
+	}
+	return attackers
+}
+
+// positionOf returns a driver's index in the running order, -1 if they aren't in it
+func positionOf(drivers []DriverState, driverNumber int) int {
+	for i, driver := range drivers {
+		if driver.DriverNumber == driverNumber {
+			return i
+		}
+	}
+	return -1
+}
+
+// dimUnfocused darkens every driver but the focused one
+func dimUnfocused(frame *Frame) {
+	for driverNumber, c := range frame.Colors {
+		if driverNumber == frame.Focus {
+			continue
+		}
+		frame.Colors[driverNumber] = scaleColor(c, unfocusedBrightness)
+		for i, trailColor := range frame.TrailColors[driverNumber] {
+			frame.TrailColors[driverNumber][i] = scaleColor(trailColor, unfocusedBrightness)
+		}
+	}
+}
+
+// followFocus moves the visualizer's camera behind the focused driver, looking at their car
+func (r *motionToolsRenderer) followFocus(frame Frame, transform sceneTransform) error {
+	if frame.Focus == 0 || !r.cfg.chaseCamera() || time.Since(r.lastChase) < chaseUpdateInterval {
+		return nil
+	}
+	index := positionOf(frame.Drivers, frame.Focus)
+	if index < 0 {
+		return nil
+	}
+	driver := frame.Drivers[index]
+
+	car := transform.apply(r3.Vector{
+		X: float64(driver.Location.X),
+		Y: float64(driver.Location.Y),
+		Z: float64(driver.Location.Z),
+	})
+	heading := transform.heading(driver.Heading) * math.Pi / 180
+	distance := r.cfg.trackWidth() * chaseDistanceRatio * transform.scale
+	position := car.Add(r3.Vector{
+		X: -distance * math.Cos(heading),
+		Y: -distance * math.Sin(heading),
+		Z: r.cfg.trackWidth() * chaseHeightRatio * transform.scale,
+	})
+	if err := vizClient.SetCameraPose(position, car, true); err != nil {
+		return err
+	}
+	r.lastChase = time.Now()
+	return nil
+}
@@ -0,0 +1,283 @@
+package f1viz
+
+import (
+	"context"
+	"fmt"
+	"image"
+	"image/color"
+	"image/color/palette"
+	"image/draw"
+	"image/gif"
+	"image/png"
+	"math"
+	"os"
+	"path/filepath"
+	"time"
+
+	"golang.org/x/image/font"
+	"golang.org/x/image/font/basicfont"
+	"golang.org/x/image/math/fixed"
+)
+
+const (
+	ImageFormatPNG = "png"
+	ImageFormatGIF = "gif"
+
+	defaultImageSize          = 800
+	defaultImageFrameInterval = 200 * time.Millisecond
+	// imageMarginRatio is the blank border around the track as a fraction of the image size
+	imageMarginRatio = 0.05
+)
+
+var (
+	imageBackground   = color.NRGBA{R: 21, G: 21, B: 30, A: 255}
+	imageTextColor    = color.NRGBA{R: 255, G: 255, B: 255, A: 255}
+	imagePitLaneColor = color.NRGBA{R: 128, G: 128, B: 128, A: 255}
+)
+
+// ImageRendererOptions configures an image renderer
+type ImageRendererOptions struct {
+	Dir    string // Directory frames are written to, created if needed
+	Format string // ImageFormatPNG for a numbered PNG sequence or ImageFormatGIF for one animated GIF
+	Width  int    // Defaults to 800
+	Height int    // Defaults to 800
+	// Playback time between frames. Defaults to 200ms.
+	Interval time.Duration
+	// How much faster than real time an animated GIF plays. Defaults to 1.
+	Speed float64
+}
+
+// trackCanvas rasterises the track and drivers into images of a fixed size
+type trackCanvas struct {
+	width, height int
+
+	// Track drawn into an image, and how OpenF1 X/Y map onto it
+	track              *image.NRGBA
+	minX, maxY, scale  float64
+	offsetX, offsetY   float64
+	trackWidth, carRad float64
+}
+
+// imageRenderer draws frames of the replay into images at a fixed resolution and saves them to disk
+type imageRenderer struct {
+	opts   ImageRendererOptions
+	canvas *trackCanvas
+
+	lastFrame time.Time
+	frames    int
+	gif       *gif.GIF
+}
+
+// NewImageRenderer returns a renderer that writes the replay as a PNG sequence or an animated GIF.
+// Frames are taken every Interval of playback time; a GIF is written when the renderer is closed.
+func NewImageRenderer(opts ImageRendererOptions) (Renderer, error) {
+	switch opts.Format {
+	case ImageFormatPNG, ImageFormatGIF:
+	default:
+		return nil, fmt.Errorf("image format must be %q or %q, got %q", ImageFormatPNG, ImageFormatGIF, opts.Format)
+	}
+	if opts.Width <= 0 {
+		opts.Width = defaultImageSize
+	}
+	if opts.Height <= 0 {
+		opts.Height = defaultImageSize
+	}
+	if opts.Interval <= 0 {
+		opts.Interval = defaultImageFrameInterval
+	}
+	if opts.Speed <= 0 {
+		opts.Speed = 1
+	}
+	if err := os.MkdirAll(opts.Dir, 0o755); err != nil {
+		return nil, fmt.Errorf("failed to create %s: %w", opts.Dir, err)
+	}
+
+	r := &imageRenderer{opts: opts, canvas: newTrackCanvas(opts.Width, opts.Height)}
+	if opts.Format == ImageFormatGIF {
+		r.gif = &gif.GIF{}
+	}
+	return r, nil
+}
+
+// newTrackCanvas returns a canvas of width by height pixels
+func newTrackCanvas(width, height int) *trackCanvas {
+	return &trackCanvas{width: width, height: height}
+}
+
+// pixel returns image coordinates for an OpenF1 X/Y, with north up
+func (c *trackCanvas) pixel(x, y int) (float64, float64) {
+	return c.offsetX + (float64(x)-c.minX)*c.scale, c.offsetY + (c.maxY-float64(y))*c.scale
+}
+
+// drawTrack fits the track to the canvas and draws it
+func (c *trackCanvas) drawTrack(scene TrackScene) {
+	points := scene.Track.Points
+	c.minX, c.maxY = math.MaxFloat64, -math.MaxFloat64
+	maxX, minY := -math.MaxFloat64, math.MaxFloat64
+	for _, p := range points {
+		c.minX, maxX = math.Min(c.minX, float64(p.X)), math.Max(maxX, float64(p.X))
+		minY, c.maxY = math.Min(minY, float64(p.Y)), math.Max(c.maxY, float64(p.Y))
+	}
+
+	w, h := float64(c.width), float64(c.height)
+	margin := math.Min(w, h) * imageMarginRatio
+	spanX, spanY := math.Max(maxX-c.minX, 1), math.Max(c.maxY-minY, 1)
+	c.scale = math.Min((w-2*margin)/spanX, (h-2*margin)/spanY)
+	c.offsetX = (w - spanX*c.scale) / 2
+	c.offsetY = (h - spanY*c.scale) / 2
+	c.trackWidth = math.Max(2, math.Min(w, h)/150)
+	c.carRad = c.trackWidth * 1.5
+
+	c.track = c.blank()
+	if pit := scene.Track.PitLane; pit != nil {
+		for i := 1; i < len(pit.Points); i++ {
+			c.line(c.track, pit.Points[i-1], pit.Points[i], c.trackWidth/2, imagePitLaneColor)
+		}
+	}
+	for i := range points {
+		c.line(c.track, points[i], points[(i+1)%len(points)], c.trackWidth, scene.Colors[i])
+	}
+}
+
+// blank returns an empty image the size of the canvas
+func (c *trackCanvas) blank() *image.NRGBA {
+	img := image.NewNRGBA(image.Rect(0, 0, c.width, c.height))
+	draw.Draw(img, img.Bounds(), image.NewUniform(imageBackground), image.Point{}, draw.Src)
+	return img
+}
+
+// line draws a thick line between two OpenF1 points
+func (c *trackCanvas) line(img *image.NRGBA, a, b TrackPoint, width float64, lineColor color.NRGBA) {
+	x0, y0 := c.pixel(a.X, a.Y)
+	x1, y1 := c.pixel(b.X, b.Y)
+	steps := max(1, int(math.Ceil(math.Hypot(x1-x0, y1-y0))))
+	for i := 0; i <= steps; i++ {
+		f := float64(i) / float64(steps)
+		fillCircle(img, x0+f*(x1-x0), y0+f*(y1-y0), width/2, lineColor)
+	}
+}
+
+// drawFrame returns the track with the frame's drivers, labels and running order drawn over it,
+// or a blank image if no track has been drawn
+func (c *trackCanvas) drawFrame(frame Frame) *image.NRGBA {
+	if c.track == nil {
+		return c.blank()
+	}
+	img := image.NewNRGBA(c.track.Bounds())
+	copy(img.Pix, c.track.Pix)
+	if len(frame.Drivers) == 0 {
+		return img
+	}
+
+	// Leaders are drawn last so they are on top
+	for i := len(frame.Drivers) - 1; i >= 0; i-- {
+		driver := frame.Drivers[i]
+		x, y := c.pixel(driver.Location.X, driver.Location.Y)
+		fillCircle(img, x, y, c.carRad, frame.Colors[driver.DriverNumber])
+		drawText(img, int(x+c.carRad)+2, int(y-c.carRad)-basicfont.Face7x13.Height, frame.Acronyms[driver.DriverNumber], imageTextColor)
+	}
+
+	// Lap, playback time and running order down the left
+	header := fmt.Sprintf("Lap %d  %s", frame.Drivers[0].Lap, frame.PlaybackTime.UTC().Format("15:04:05"))
+	drawText(img, 10, 10, header, imageTextColor)
+	for i, driver := range frame.Drivers {
+		y := 10 + (i+2)*basicfont.Face7x13.Height
+		position := driver.Position
+		if position == 0 {
+			position = i + 1
+		}
+		fillCircle(img, 14, float64(y)+6, 4, frame.Colors[driver.DriverNumber])
+		drawText(img, 22, y, fmt.Sprintf("%2d %s", position, frame.Acronyms[driver.DriverNumber]), imageTextColor)
+	}
+	return img
+}
+
+// fillCircle draws a filled circle
+func fillCircle(img *image.NRGBA, cx, cy, radius float64, c color.NRGBA) {
+	bounds := img.Bounds()
+	for y := int(math.Floor(cy - radius)); y <= int(math.Ceil(cy+radius)); y++ {
+		for x := int(math.Floor(cx - radius)); x <= int(math.Ceil(cx+radius)); x++ {
+			if !image.Pt(x, y).In(bounds) {
+				continue
+			}
+			dx, dy := float64(x)-cx, float64(y)-cy
+			if dx*dx+dy*dy <= radius*radius {
+				img.SetNRGBA(x, y, c)
+			}
+		}
+	}
+}
+
+// drawText writes text with its top left corner at x, y
+func drawText(img *image.NRGBA, x, y int, text string, c color.NRGBA) {
+	d := &font.Drawer{
+		Dst:  img,
+		Src:  image.NewUniform(c),
+		Face: basicfont.Face7x13,
+		Dot:  fixed.P(x, y+basicfont.Face7x13.Ascent),
+	}
+	d.DrawString(text)
+}
+
+// DrawTrack fits the track to the image and draws it
+func (r *imageRenderer) DrawTrack(ctx context.Context, scene TrackScene) error {
+	r.canvas.drawTrack(scene)
+	return nil
+}
+
+// DrawFrame draws the drivers over the track and saves the image, at most once per Interval of playback time
+func (r *imageRenderer) DrawFrame(ctx context.Context, frame Frame) error {
+	if r.canvas.track == nil || len(frame.Drivers) == 0 {
+		return nil
+	}
+	if !r.lastFrame.IsZero() && frame.PlaybackTime.Sub(r.lastFrame) < r.opts.Interval {
+		return nil
+	}
+	r.lastFrame = frame.PlaybackTime
+	return r.save(r.canvas.drawFrame(frame))
+}
+
+// save writes a PNG or adds a frame to the GIF
+func (r *imageRenderer) save(img *image.NRGBA) error {
+	r.frames++
+	if r.gif != nil {
+		paletted := image.NewPaletted(img.Bounds(), palette.Plan9)
+		draw.Draw(paletted, paletted.Bounds(), img, image.Point{}, draw.Src)
+		r.gif.Image = append(r.gif.Image, paletted)
+		// GIF delays are in hundredths of a second
+		r.gif.Delay = append(r.gif.Delay, max(1, int(math.Round(r.opts.Interval.Seconds()*100/r.opts.Speed))))
+		return nil
+	}
+
+	f, err := os.Create(filepath.Join(r.opts.Dir, fmt.Sprintf("frame_%05d.png", r.frames)))
+	if err != nil {
+		return err
+	}
+	if err := png.Encode(f, img); err != nil {
+		f.Close()
+		return err
+	}
+	return f.Close()
+}
+
+// Clear does nothing; frames already written are kept
+func (r *imageRenderer) Clear(ctx context.Context) error {
+	return nil
+}
+
+// Close writes the animated GIF, if there is one
+func (r *imageRenderer) Close(ctx context.Context) error {
+	if r.gif == nil || len(r.gif.Image) == 0 {
+		return nil
+	}
+	f, err := os.Create(filepath.Join(r.opts.Dir, "replay.gif"))
+	if err != nil {
+		return err
+	}
+	if err := gif.EncodeAll(f, r.gif); err != nil {
+		f.Close()
+		return err
+	}
+	r.gif = nil
+	return f.Close()
+}
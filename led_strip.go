@@ -0,0 +1,146 @@
+package f1viz
+
+import (
+	"context"
+	"fmt"
+	"image/color"
+	"sort"
+	"sync"
+	"time"
+
+	"go.viam.com/rdk/components/board"
+)
+
+const (
+	// defaultLEDCommand is the board DoCommand key that sets every LED on the strip
+	defaultLEDCommand = "set_pixels"
+	// ledUpdateInterval is the shortest time between writes to the LED strip
+	ledUpdateInterval = 50 * time.Millisecond
+)
+
+// ledStrip lights one LED per reference track index through a board's DoCommand.
+// The board is sent {"<command>": [[r, g, b], ...]} with one entry per LED, starting at the first LED.
+type ledStrip struct {
+	board   board.Board
+	command string
+	offset  int  // LED lit for track index 0
+	reverse bool // The strip runs against the direction of the track
+
+	mu        sync.Mutex
+	last      [][3]uint8
+	lastWrite time.Time
+}
+
+// newLEDStrip returns an LED strip driven by the given board
+func newLEDStrip(b board.Board, cfg *Config) *ledStrip {
+	command := cfg.LEDCommand
+	if command == "" {
+		command = defaultLEDCommand
+	}
+	return &ledStrip{
+		board:   b,
+		command: command,
+		offset:  cfg.LEDOffset,
+		reverse: cfg.LEDReverse,
+	}
+}
+
+// ledIndex returns the LED for a track index on a strip of n LEDs
+func (l *ledStrip) ledIndex(trackIndex, n int) int {
+	led := ((trackIndex+l.offset)%n + n) % n
+	if l.reverse {
+		led = n - 1 - led
+	}
+	return led
+}
+
+// frame returns the colour of every LED, lighting each driver's track index. Drivers further
+// ahead are lit last so the leader wins when two cars share an LED.
+func (l *ledStrip) frame(drivers []DriverState, n int, colorOf func(int) color.NRGBA) [][3]uint8 {
+	leds := make([][3]uint8, n)
+	sort.Slice(drivers, func(i, j int) bool {
+		return drivers[i].RaceDistance < drivers[j].RaceDistance
+	})
+	for _, driver := range drivers {
+		if driver.TrackIndex < 0 {
+			continue
+		}
+		c := colorOf(driver.DriverNumber)
+		leds[l.ledIndex(driver.TrackIndex, n)] = [3]uint8{c.R, c.G, c.B}
+	}
+	return leds
+}
+
+// write sends a frame to the board, skipping frames that are unchanged or arrive too soon after the last one
+func (l *ledStrip) write(ctx context.Context, leds [][3]uint8, force bool) error {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if !force && (time.Since(l.lastWrite) < ledUpdateInterval || sameLEDs(leds, l.last)) {
+		return nil
+	}
+
+	pixels := make([]interface{}, len(leds))
+	for i, c := range leds {
+		pixels[i] = []interface{}{int(c[0]), int(c[1]), int(c[2])}
+	}
+	if _, err := l.board.DoCommand(ctx, map[string]interface{}{l.command: pixels}); err != nil {
+		return fmt.Errorf("failed to set LEDs: %w", err)
+	}
+	l.last = leds
+	l.lastWrite = time.Now()
+	return nil
+}
+
+// clear turns every LED off
+func (l *ledStrip) clear(ctx context.Context) error {
+	l.mu.Lock()
+	n := len(l.last)
+	l.mu.Unlock()
+	if n == 0 {
+		return nil
+	}
+	return l.write(ctx, make([][3]uint8, n), true)
+}
+
+// sameLEDs reports whether two frames are identical
+func sameLEDs(a, b [][3]uint8) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for i := range a {
+		if a[i] != b[i] {
+			return false
+		}
+	}
+	return true
+}
+
+// DrawTrack does nothing; the strip itself is the track
+func (l *ledStrip) DrawTrack(ctx context.Context, scene TrackScene) error {
+	return nil
+}
+
+// DrawFrame lights every driver's position on the strip, one LED per reference track index
+func (l *ledStrip) DrawFrame(ctx context.Context, frame Frame) error {
+	if frame.Track == nil {
+		return nil
+	}
+	colorOf := func(driverNumber int) color.NRGBA {
+		if c, ok := frame.Colors[driverNumber]; ok {
+			return c
+		}
+		return driverColor(driverNumber)
+	}
+	drivers := append([]DriverState(nil), frame.Drivers...)
+	return l.write(ctx, l.frame(drivers, len(frame.Track.Points), colorOf), false)
+}
+
+// Clear turns every LED off
+func (l *ledStrip) Clear(ctx context.Context) error {
+	return l.clear(ctx)
+}
+
+// Close turns every LED off
+func (l *ledStrip) Close(ctx context.Context) error {
+	return l.clear(ctx)
+}
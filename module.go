@@ -5,19 +5,16 @@ import (
 	"encoding/json"
 	"fmt"
 	"image/color"
-	"io"
-	"net/http"
 	"net/url"
 	"os"
 	"path/filepath"
+	"sort"
 	"sync"
 	"sync/atomic"
 	"time"
 
 	"github.com/golang/geo/r3"
-	vizClient "github.com/viam-labs/motion-tools/client/client"
 	"go.viam.com/rdk/logging"
-	"go.viam.com/rdk/pointcloud"
 	"go.viam.com/rdk/resource"
 	generic "go.viam.com/rdk/services/generic"
 	"go.viam.com/utils"
@@ -31,18 +28,6 @@ var (
 	}
 )
 
-type TrackPoint struct {
-	X int `json:"x"`
-	Y int `json:"y"`
-	Z int `json:"z"`
-}
-
-// ReferenceTrack contains 144 points representing the track layout (one per index 0-143)
-type ReferenceTrack struct {
-	StartPoint TrackPoint   `json:"start_point"`
-	Points     []TrackPoint `json:"points"` // 144 points, index 0-143
-}
-
 const (
 	circuitKey  = 9
 	sessionName = "Race"
@@ -52,12 +37,12 @@ const (
 	fetchWindowDuration = time.Minute
 	// Threshold to trigger next fetch when buffer drops below this percentage
 	bufferLowThreshold = 0.2
-	referenceTrackFile = "reference_track.json"
 )
 
 // Session represents a session from the OpenF1 API
 type Session struct {
 	SessionKey int    `json:"session_key"`
+	CircuitKey int    `json:"circuit_key"`
 	DateStart  string `json:"date_start"`
 	DateEnd    string `json:"date_end"`
 }
@@ -71,6 +56,10 @@ type Location struct {
 	X            int    `json:"x"`
 	Y            int    `json:"y"`
 	Z            int    `json:"z"`
+	// Position along the reference track (0 to N-1), filled in by the consumer; -1 if unknown
+	TrackIndex int `json:"track_index"`
+	// Telemetry at this location, filled in by the fetcher; nil if unknown
+	CarData *CarData `json:"car_data,omitempty"`
 }
 
 func init() {
@@ -82,7 +71,51 @@ func init() {
 }
 
 type Config struct {
+	// Board whose LED strip shows each driver's position on the reference track, one LED per track index
 	Board string `json:"board"`
+	// Board DoCommand key that sets the LEDs, sent a list of [r, g, b] per LED. Defaults to "set_pixels".
+	LEDCommand string `json:"led_command,omitempty"`
+	// LED lit for track index 0, for strips that do not start at the start/finish line
+	LEDOffset int `json:"led_offset,omitempty"`
+	// The LED strip runs against the direction of the track
+	LEDReverse bool `json:"led_reverse,omitempty"`
+	// Directory of reference tracks named <circuit_key>.json. Relative paths are resolved against
+	// the module root. Defaults to the bundled reference_tracks directory.
+	ReferenceTrackDir string `json:"reference_track_dir,omitempty"`
+	// Number of points in the reference track, e.g. 60 for a 60 LED strip. Defaults to 144.
+	// Tracks in the library with a different resolution are resampled when loaded.
+	TrackResolution int `json:"track_resolution,omitempty"`
+	// How the reference track is drawn: "line" (default), "ribbon" or "points"
+	TrackStyle string `json:"track_style,omitempty"`
+	// Width of the ribbon and markers in OpenF1 units. Defaults to 150.
+	TrackWidth float64 `json:"track_width,omitempty"`
+	// Draw a line across the track at the start/finish
+	StartFinishLine bool `json:"start_finish_line,omitempty"`
+	// Draw a line across the track at each sector boundary
+	SectorMarkers bool `json:"sector_markers,omitempty"`
+	// How OpenF1 coordinates are placed in the scene for everything that is drawn
+	Transform *TransformConfig `json:"transform,omitempty"`
+	// Where the replay is drawn: any of "motion_tools", "led_strip" and "dashboard". Defaults to motion_tools,
+	// plus led_strip when a board is configured and dashboard when dashboard_address is set.
+	Renderers []string `json:"renderers,omitempty"`
+	// Address the web dashboard listens on, e.g. ":8090". Setting it enables the dashboard.
+	DashboardAddress string `json:"dashboard_address,omitempty"`
+	// How trails and car boxes are coloured: "fade" (default) fades each driver's colour along the trail,
+	// while "speed", "throttle", "brake" and "gear" colour each trail point and car by that telemetry channel
+	TrailColor string `json:"trail_color,omitempty"`
+	// How cars are drawn in the visualizer: "box" (default), a box pointing in the direction of travel, or
+	// "none" to show only trails
+	CarStyle string `json:"car_style,omitempty"`
+	// How many times life size cars are drawn. Defaults to 3 so they stay visible next to a whole circuit.
+	CarScale float64 `json:"car_scale,omitempty"`
+	// Highlight one driver at a time, cycling between battles if auto_cycle is set. Can be changed with focus.
+	Focus *FocusConfig `json:"focus,omitempty"`
+	// Trail length, fade curve and lap ghosts. Can be changed per driver with set_trail.
+	Trail *TrailConfig `json:"trail,omitempty"`
+	// Don't draw each driver's label above their car in the visualizer
+	HideLabels bool `json:"hide_labels,omitempty"`
+	// Don't draw the running order next to the track in the visualizer
+	HideLeaderboard bool `json:"hide_leaderboard,omitempty"`
 }
 
 // Validate ensures all parts of the config are valid and important fields exist.
@@ -96,8 +129,56 @@ type Config struct {
 // (for example, "components.0"). You can use it in error messages
 // to indicate which resource has a problem.
 func (cfg *Config) Validate(path string) ([]string, []string, error) {
-	// Add config validation code here
-	return nil, nil, nil
+	if cfg.TrackResolution != 0 && cfg.TrackResolution < minTrackResolution {
+		return nil, nil, fmt.Errorf("%s: track_resolution must be at least %d, got %d", path, minTrackResolution, cfg.TrackResolution)
+	}
+	switch cfg.TrackStyle {
+	case "", trackStyleLine, trackStyleRibbon, trackStylePoints:
+	default:
+		return nil, nil, fmt.Errorf("%s: track_style must be %q, %q or %q, got %q",
+			path, trackStyleLine, trackStyleRibbon, trackStylePoints, cfg.TrackStyle)
+	}
+	if cfg.TrackWidth < 0 {
+		return nil, nil, fmt.Errorf("%s: track_width must not be negative", path)
+	}
+	if cfg.Transform != nil {
+		if err := cfg.Transform.validate(path); err != nil {
+			return nil, nil, err
+		}
+	}
+	if cfg.TrailColor != "" {
+		if err := validateTrailColor(cfg.TrailColor); err != nil {
+			return nil, nil, fmt.Errorf("%s: %w", path, err)
+		}
+	}
+	if cfg.CarStyle != "" {
+		if err := validateCarStyle(cfg.CarStyle); err != nil {
+			return nil, nil, fmt.Errorf("%s: %w", path, err)
+		}
+	}
+	if cfg.CarScale < 0 {
+		return nil, nil, fmt.Errorf("%s: car_scale must not be negative", path)
+	}
+	if cfg.Focus != nil {
+		if err := cfg.Focus.validate(path); err != nil {
+			return nil, nil, err
+		}
+	}
+	if cfg.Trail != nil {
+		if err := cfg.Trail.validate(path); err != nil {
+			return nil, nil, err
+		}
+	}
+	if err := cfg.validateRenderers(path); err != nil {
+		return nil, nil, err
+	}
+	if cfg.Board == "" {
+		return nil, nil, nil
+	}
+	if cfg.LEDOffset < 0 {
+		return nil, nil, fmt.Errorf("%s: led_offset must not be negative", path)
+	}
+	return []string{cfg.Board}, nil, nil
 }
 
 type vizF1viz struct {
@@ -111,12 +192,51 @@ type vizF1viz struct {
 	cancelCtx  context.Context
 	cancelFunc func()
 
-	referenceTrack ReferenceTrack
+	// Everything the replay is drawn with
+	renderers []Renderer
+
+	// Active reference track, nil until one is loaded or generated
+	trackMu        sync.RWMutex
+	referenceTrack *ReferenceTrack
+	trackLibrary   *trackLibrary
+
+	// Serialises calls to the renderers
+	drawMu sync.Mutex
 
 	// For producer-consumer pattern
-	locationChans []chan Location // One channel per driver
-	workers       *utils.StoppableWorkers
-	started       atomic.Bool
+	workers *utils.StoppableWorkers
+	started atomic.Bool
+
+	// Driver streams currently being fetched, keyed by driver number
+	streamsMu    sync.Mutex
+	streams      map[int]*driverStream
+	exhausted    map[int]bool // Drivers whose location data has run out
+	playbackTime time.Time    // Date of the most recently rendered location data
+	playbackEnd  time.Time    // Date the replay stops at, zero to play until the data runs out
+
+	// Latest state of each rendered driver, keyed by driver number
+	stateMu      sync.RWMutex
+	driverStates map[int]DriverState
+	sectorTiming *sectorTiming
+	driverInfo   map[int]Driver      // OpenF1 driver entries in the current session
+	teamColors   map[int]color.NRGBA // OpenF1 team colour per driver in the current session
+	flags        *flagTimeline       // Flags through the current session, nil if unknown
+	positions    *positionTimeline   // OpenF1 race positions through the current session, nil if unknown
+
+	// Trail colour mode set with set_trail_color, overriding the configured one when not empty
+	trailColorMode string
+
+	// Trail settings set with set_trail, for every driver and per driver
+	trailDefault   *trailSettings
+	trailOverrides map[int]trailSettings
+
+	// Driver highlighted by focus, 0 for none, and whether focus cycles between battles
+	focusDriver   int
+	focusAuto     bool
+	focusSwitched time.Time // When auto focus last moved to another driver
+
+	// Rendered locations per driver since the replay started, thinned out as they are recorded, for export
+	recordedPaths map[int][]Location
 
 	// Timestamp tracking
 	timestampData []RoundTimestamp
@@ -148,6 +268,16 @@ func newVizF1viz(ctx context.Context, deps resource.Dependencies, rawConf resour
 }
 
 func NewF1viz(ctx context.Context, deps resource.Dependencies, name resource.Name, conf *Config, logger logging.Logger) (resource.Resource, error) {
+	renderers, err := newRenderers(deps, conf, logger)
+	if err != nil {
+		return nil, err
+	}
+	return NewF1vizWithRenderers(ctx, deps, name, conf, logger, renderers)
+}
+
+// NewF1vizWithRenderers creates the service drawing with the given renderers instead of the configured ones,
+// for programs that embed it such as the CLI
+func NewF1vizWithRenderers(ctx context.Context, deps resource.Dependencies, name resource.Name, conf *Config, logger logging.Logger, renderers []Renderer) (resource.Resource, error) {
 
 	cancelCtx, cancelFunc := context.WithCancel(context.Background())
 
@@ -158,13 +288,20 @@ func NewF1viz(ctx context.Context, deps resource.Dependencies, name resource.Nam
 		cancelCtx:  cancelCtx,
 		cancelFunc: cancelFunc,
 		started:    atomic.Bool{},
+		renderers:  renderers,
+		focusAuto:  conf.Focus != nil && conf.Focus.AutoCycle,
 	}
+	s.trackLibrary = newTrackLibrary(conf)
+	registerLocalService(s)
 
-	referenceTrack, err := loadReferenceTrack()
+	referenceTrack, path, err := s.trackLibrary.load(circuitKey)
 	if err != nil {
-		return nil, fmt.Errorf("failed to load reference track: %w", err)
+		// The track is generated when a session starts, or with the generate_reference_track command
+		s.logger.Warnf("No reference track loaded for circuit %d: %v", circuitKey, err)
+	} else {
+		s.logger.Infof("Loaded reference track for circuit %d from %s", circuitKey, path)
+		s.referenceTrack = referenceTrack
 	}
-	s.referenceTrack = referenceTrack
 	return s, nil
 }
 
@@ -186,12 +323,34 @@ func (s *vizF1viz) DoCommand(ctx context.Context, cmd map[string]interface{}) (m
 		return map[string]interface{}{
 			"status": "success",
 		}, nil
+	case "generate_reference_track":
+		return s.generateReferenceTrackCommand(ctx, cmd[commandKey])
 	case "start":
-		s.drawReferenceTrack()
 		return s.start(ctx, cmd[commandKey])
+	case "get_state":
+		return toCommandResponse(s.raceState())
+	case "set_trail_color":
+		return s.setTrailColorCommand(cmd[commandKey])
+	case "set_trail":
+		return s.setTrailCommand(cmd[commandKey])
+	case "focus":
+		return s.focusCommand(cmd[commandKey])
+	case "export":
+		return s.exportCommand(cmd[commandKey])
+	case "get_sector_times":
+		return s.getSectorTimes()
+	case "get_progress":
+		return toCommandResponse(map[string]interface{}{
+			"playback_time": s.raceState().PlaybackTime,
+			"running_order": s.runningOrder(),
+		})
 	case "stop":
-		s.workers.Stop()
+		if s.workers != nil {
+			s.workers.Stop()
+		}
 		s.workers = utils.NewStoppableWorkers(s.cancelCtx)
+		s.clearRenderers(ctx)
+		s.clearRecordedPaths()
 		// Write timestamps to disk
 		if err := s.writeTimestampsToDisk(); err != nil {
 			s.logger.Errorf("Failed to write timestamps to disk: %v", err)
@@ -203,46 +362,136 @@ func (s *vizF1viz) DoCommand(ctx context.Context, cmd map[string]interface{}) (m
 	}
 }
 
-func (s *vizF1viz) start(ctx context.Context, cmdValue interface{}) (map[string]interface{}, error) {
-	if !s.started.CompareAndSwap(false, true) {
-		return nil, fmt.Errorf("already started")
-	}
+// startOptions describes which drivers a start command should stream, and over which part of which session
+type startOptions struct {
+	driverNumbers []int // Fixed set of drivers
+	top           int   // When > 0, follow the top N cars by race position instead
 
-	// Parse driver numbers from command
-	var driverNumbers []int
+	sessionKey int    // Session to replay; defaults to the configured session
+	from       string // Where to start: an RFC3339 time or a duration after the session start such as "45m"
+	to         string // Where to stop, in the same form as from; defaults to the end of the data
+}
 
+// startRequest is the map form of a start command
+type startRequest struct {
+	Drivers    []int  `json:"drivers"`
+	Top        int    `json:"top"`
+	SessionKey int    `json:"session_key"`
+	From       string `json:"from"`
+	To         string `json:"to"`
+}
+
+// parseStartCommand parses the value of a start command. It accepts either a
+// list of driver numbers or a map such as {"top": 5} or
+// {"drivers": [1, 11], "session_key": 9157, "from": "45m", "to": "50m"}.
+func parseStartCommand(cmdValue interface{}) (startOptions, error) {
 	// Handle []int directly
 	if nums, ok := cmdValue.([]int); ok {
-		driverNumbers = nums
-	} else if nums, ok := cmdValue.([]interface{}); ok {
-		// Handle []interface{} from JSON parsing
-		driverNumbers = make([]int, 0, len(nums))
-		for i, v := range nums {
-			var num int
-			switch n := v.(type) {
-			case int:
-				num = n
-			case int64:
-				num = int(n)
-			case float64:
-				num = int(n)
-			default:
-				return nil, fmt.Errorf("start command: element at index %d is not a number, got %T", i, v)
-			}
-			driverNumbers = append(driverNumbers, num)
+		if len(nums) == 0 {
+			return startOptions{}, fmt.Errorf("start command requires at least one driver number")
 		}
-	} else {
-		return nil, fmt.Errorf("start command expects a list of integers, got %T", cmdValue)
+		return startOptions{driverNumbers: nums}, nil
+	}
+
+	// Handle {"top": N} and {"drivers": [...]}, with an optional session and time range
+	if m, ok := cmdValue.(map[string]interface{}); ok {
+		var req startRequest
+		if err := decodeCommandArgs(m, &req); err != nil {
+			return startOptions{}, fmt.Errorf("start command: %w", err)
+		}
+		opts := startOptions{
+			driverNumbers: req.Drivers,
+			top:           req.Top,
+			sessionKey:    req.SessionKey,
+			from:          req.From,
+			to:            req.To,
+		}
+		if _, ok := m["top"]; ok && opts.top <= 0 {
+			return startOptions{}, fmt.Errorf("start command: top must be positive, got %d", opts.top)
+		}
+		if opts.top == 0 && len(opts.driverNumbers) == 0 {
+			return startOptions{}, fmt.Errorf("start command map must contain \"top\" or \"drivers\"")
+		}
+		return opts, nil
 	}
 
+	// Handle []interface{} from JSON parsing
+	nums, ok := cmdValue.([]interface{})
+	if !ok {
+		return startOptions{}, fmt.Errorf("start command expects a list of integers or {\"top\": N}, got %T", cmdValue)
+	}
+	driverNumbers := make([]int, 0, len(nums))
+	for i, v := range nums {
+		num, err := toInt(v)
+		if err != nil {
+			return startOptions{}, fmt.Errorf("start command: element at index %d: %w", i, err)
+		}
+		driverNumbers = append(driverNumbers, num)
+	}
 	if len(driverNumbers) == 0 {
-		return nil, fmt.Errorf("start command requires at least one driver number")
+		return startOptions{}, fmt.Errorf("start command requires at least one driver number")
+	}
+	return startOptions{driverNumbers: driverNumbers}, nil
+}
+
+// replayTime parses a start command time: an RFC3339 time, or a duration after the session start
+func replayTime(value string, sessionStart time.Time) (time.Time, error) {
+	if t, err := time.Parse(time.RFC3339, value); err == nil {
+		return t, nil
+	}
+	offset, err := time.ParseDuration(value)
+	if err != nil {
+		return time.Time{}, fmt.Errorf("%q is neither an RFC3339 time nor a duration", value)
+	}
+	return sessionStart.Add(offset), nil
+}
+
+// decodeCommandArgs decodes the value of a DoCommand entry into a typed struct via JSON
+func decodeCommandArgs(cmdValue interface{}, out interface{}) error {
+	if cmdValue == nil {
+		return nil
+	}
+	data, err := json.Marshal(cmdValue)
+	if err != nil {
+		return fmt.Errorf("failed to encode arguments: %w", err)
+	}
+	if err := json.Unmarshal(data, out); err != nil {
+		return fmt.Errorf("invalid arguments: %w", err)
+	}
+	return nil
+}
+
+// toInt converts a JSON number to an int
+func toInt(v interface{}) (int, error) {
+	switch n := v.(type) {
+	case int:
+		return n, nil
+	case int64:
+		return int(n), nil
+	case float64:
+		return int(n), nil
+	default:
+		return 0, fmt.Errorf("not a number, got %T", v)
 	}
+}
 
-	s.logger.Infof("Starting with driver numbers: %v", driverNumbers)
+func (s *vizF1viz) start(ctx context.Context, cmdValue interface{}) (map[string]interface{}, error) {
+	opts, err := parseStartCommand(cmdValue)
+	if err != nil {
+		return nil, err
+	}
+
+	if !s.started.CompareAndSwap(false, true) {
+		return nil, fmt.Errorf("already started")
+	}
 
 	// Fetch session first
-	session, err := s.fetchSession(ctx)
+	var session Session
+	if opts.sessionKey == 0 {
+		session, err = s.fetchSession(ctx)
+	} else {
+		session, err = fetchSessionByKey(ctx, opts.sessionKey)
+	}
 	if err != nil {
 		s.started.CompareAndSwap(true, false)
 		return nil, fmt.Errorf("failed to fetch session: %w", err)
@@ -256,45 +505,89 @@ func (s *vizF1viz) start(ctx context.Context, cmdValue interface{}) (map[string]
 		s.started.CompareAndSwap(true, false)
 		return nil, fmt.Errorf("failed to parse session start time: %w", err)
 	}
+	sessionStart := startTime
+	var endTime time.Time
+	if opts.from != "" {
+		if startTime, err = replayTime(opts.from, sessionStart); err != nil {
+			s.started.CompareAndSwap(true, false)
+			return nil, fmt.Errorf("start command: from: %w", err)
+		}
+	}
+	if opts.to != "" {
+		if endTime, err = replayTime(opts.to, sessionStart); err != nil {
+			s.started.CompareAndSwap(true, false)
+			return nil, fmt.Errorf("start command: to: %w", err)
+		}
+		if !endTime.After(startTime) {
+			s.started.CompareAndSwap(true, false)
+			return nil, fmt.Errorf("start command: to must be after from")
+		}
+	}
+
+	// Race positions give the running order; without them drivers are ranked by race distance
+	positions, err := s.fetchPositionTimeline(ctx, sessionKey)
+	if err != nil && opts.top > 0 {
+		s.started.CompareAndSwap(true, false)
+		return nil, fmt.Errorf("failed to fetch position data: %w", err)
+	} else if err != nil {
+		s.logger.Warnf("Failed to fetch position data, ranking drivers by race distance: %v", err)
+	}
+	if opts.top > 0 {
+		opts.driverNumbers = positions.topN(startTime, opts.top)
+		if len(opts.driverNumbers) == 0 {
+			s.started.CompareAndSwap(true, false)
+			return nil, fmt.Errorf("no position data found for session %d", sessionKey)
+		}
+		s.logger.Infof("Following top %d drivers, starting with: %v", opts.top, opts.driverNumbers)
+	} else {
+		s.logger.Infof("Starting with driver numbers: %v", opts.driverNumbers)
+	}
 
-	// Create buffered channel for each driver
-	s.locationChans = make([]chan Location, len(driverNumbers))
-	for i := range s.locationChans {
-		s.locationChans[i] = make(chan Location, locationChannelBuffer)
+	// Driver names and team colours are cosmetic, so carry on with numbers and the default palette without them
+	if err := s.loadDrivers(ctx, sessionKey); err != nil {
+		s.logger.Warnf("Failed to fetch drivers: %v", err)
+	}
+	flags, err := fetchFlagTimeline(ctx, sessionKey)
+	if err != nil {
+		s.logger.Warnf("Failed to fetch race control messages, assuming green flags: %v", err)
+	}
+	s.stateMu.Lock()
+	s.flags = flags
+	s.positions = positions
+	s.stateMu.Unlock()
+
+	// Pick the reference track for this circuit, generating one if the library has none
+	if err := s.selectReferenceTrack(ctx, session, opts.driverNumbers); err != nil {
+		s.logger.Warnf("Continuing without a reference track: %v", err)
+	} else if err := s.drawReferenceTrack(); err != nil {
+		s.logger.Warnf("Failed to draw reference track: %v", err)
 	}
 
 	// Create StoppableWorkers using cancelCtx
 	s.workers = utils.NewStoppableWorkers(s.cancelCtx)
 
-	// Create a fetcher worker for each driver
-	for i, driverNumber := range driverNumbers {
-		driverNum := driverNumber // Capture for closure
-		driverChan := s.locationChans[i]
+	s.streamsMu.Lock()
+	s.streams = make(map[int]*driverStream)
+	s.exhausted = make(map[int]bool)
+	s.playbackTime = startTime
+	s.playbackEnd = endTime
+	s.streamsMu.Unlock()
 
-		// Create fetcher state for this driver
-		state := &fetcherState{
-			sessionKey:      sessionKey,
-			lastFetchedTime: startTime,
-			driverNumber:    driverNum,
-		}
+	s.stateMu.Lock()
+	s.driverStates = make(map[int]DriverState)
+	s.sectorTiming = nil
+	s.recordedPaths = make(map[int][]Location)
+	s.stateMu.Unlock()
 
-		s.logger.Infof("Starting fetcher for driver %d, session %d, starting from %s", driverNum, sessionKey, startTime.Format(time.RFC3339))
+	// Create a fetcher worker for each driver
+	for _, driverNumber := range opts.driverNumbers {
+		s.addDriverStream(sessionKey, driverNumber, startTime)
+	}
 
-		// Create fetcher worker with ticker (checks buffer and fetches every 1 second)
+	// Keep the driver set in sync with the running order
+	if opts.top > 0 {
 		s.workers.Add(func(ctx context.Context) {
-			ticker := time.NewTicker(1 * time.Second)
-			defer ticker.Stop()
-			defer close(driverChan)
-
-			for {
-				select {
-				case <-ctx.Done():
-					s.logger.Infof("Fetcher for driver %d cancelled", driverNum)
-					return
-				case <-ticker.C:
-					s.fetcher(ctx, state, driverChan)
-				}
-			}
+			s.positionTracker(ctx, sessionKey, positions, opts.top)
 		})
 	}
 
@@ -304,86 +597,218 @@ func (s *vizF1viz) start(ctx context.Context, cmdValue interface{}) (map[string]
 		s.consumer(ctx)
 	})
 
+	message := fmt.Sprintf("Fetcher and consumer workers started for %d drivers", len(opts.driverNumbers))
+	if opts.top > 0 {
+		message = fmt.Sprintf("Fetcher and consumer workers started for the top %d drivers", opts.top)
+	}
+
 	// Return immediately - workers run in background
 	return map[string]interface{}{
 		"status":  "started",
-		"message": fmt.Sprintf("Fetcher and consumer workers started for %d drivers", len(driverNumbers)),
+		"message": message,
 	}, nil
 }
 
-// fetcherState holds state for the fetcher worker
-type fetcherState struct {
-	sessionKey      int
-	lastFetchedTime time.Time
-	driverNumber    int
+// driverStream is a running fetcher for a single driver's location data
+type driverStream struct {
+	driverNumber int
+	ch           chan Location
+	stop         chan struct{} // Closed to stop the fetcher without stopping the others
+	laps         []Lap         // OpenF1 laps, set by the fetcher before it sends any location
+	stints       []Stint       // OpenF1 tyre stints, set alongside laps
 }
 
-// fetcher is the work function called by the ticker-based fetcher worker
-func (s *vizF1viz) fetcher(ctx context.Context, state *fetcherState, driverChan chan Location) {
-	// Check buffer level
-	bufferLevel := float64(len(driverChan)) / float64(cap(driverChan))
-	if bufferLevel < bufferLowThreshold {
-		// Fetch next window
-		endTime := state.lastFetchedTime.Add(fetchWindowDuration)
-		locations, err := s.fetchLocationData(ctx, state.sessionKey, state.driverNumber, state.lastFetchedTime, endTime)
+// addDriverStream starts a fetcher worker for a driver, reading location data from startTime onwards.
+// It is a no-op if the driver already has a stream.
+func (s *vizF1viz) addDriverStream(sessionKey, driverNumber int, startTime time.Time) {
+	s.streamsMu.Lock()
+	defer s.streamsMu.Unlock()
+
+	if _, ok := s.streams[driverNumber]; ok {
+		return
+	}
+	stream := &driverStream{
+		driverNumber: driverNumber,
+		ch:           make(chan Location, locationChannelBuffer),
+		stop:         make(chan struct{}),
+	}
+	s.streams[driverNumber] = stream
+
+	// Create fetcher state for this driver
+	state := &fetcherState{
+		sessionKey:      sessionKey,
+		lastFetchedTime: startTime,
+		endTime:         s.playbackEnd,
+		driverNumber:    driverNumber,
+		stop:            stream.stop,
+	}
+
+	s.logger.Infof("Starting fetcher for driver %d, session %d, starting from %s", driverNumber, sessionKey, startTime.Format(time.RFC3339))
+
+	// Create fetcher worker with ticker (checks buffer and fetches every 1 second)
+	s.workers.Add(func(ctx context.Context) {
+		ticker := time.NewTicker(1 * time.Second)
+		defer ticker.Stop()
+		defer close(stream.ch)
+
+		// Lap data lets the consumer count laps for drivers joining mid-session
+		laps, err := fetchLaps(ctx, sessionKey, driverNumber)
 		if err != nil {
-			s.logger.Errorf("Failed to fetch location data for driver %d: %v", state.driverNumber, err)
-			// Continue - don't exit on error, just retry next tick
-			return
+			s.logger.Warnf("Failed to fetch laps for driver %d, counting laps from the track only: %v", driverNumber, err)
 		}
-
-		if len(locations) == 0 {
-			// No more data available for this driver - close its channel
-			s.logger.Infof("No more location data available for driver %d, closing channel", state.driverNumber)
-			close(driverChan)
-			return
+		stream.laps = laps
+		stints, err := fetchStints(ctx, sessionKey, driverNumber)
+		if err != nil {
+			s.logger.Warnf("Failed to fetch stints for driver %d: %v", driverNumber, err)
 		}
+		stream.stints = stints
 
-		// Send locations to channel
-		for _, loc := range locations {
+		for {
 			select {
 			case <-ctx.Done():
+				s.logger.Infof("Fetcher for driver %d cancelled", driverNumber)
+				return
+			case <-stream.stop:
+				s.logger.Infof("Fetcher for driver %d stopped", driverNumber)
 				return
-			case driverChan <- loc:
-				// Successfully sent
+			case <-ticker.C:
+				if done := s.fetcher(ctx, state, stream.ch); done {
+					return
+				}
 			}
 		}
+	})
+}
 
-		// Update lastFetchedTime to the last location's time + small increment
-		// to avoid fetching the same point again (using >= in query)
-		if len(locations) > 0 {
-			lastLocTime, err := time.Parse(time.RFC3339, locations[len(locations)-1].Date)
-			if err == nil {
-				// Add 1ms to avoid re-fetching the last point
-				state.lastFetchedTime = lastLocTime.Add(1 * time.Millisecond)
-			} else {
-				// If parsing fails, advance by window duration
-				state.lastFetchedTime = endTime
-			}
-		} else {
-			state.lastFetchedTime = endTime
+// removeDriverStream stops a driver's fetcher and drops it from the set the consumer reads from
+func (s *vizF1viz) removeDriverStream(driverNumber int) {
+	s.streamsMu.Lock()
+	defer s.streamsMu.Unlock()
+
+	stream, ok := s.streams[driverNumber]
+	if !ok {
+		return
+	}
+	delete(s.streams, driverNumber)
+	close(stream.stop)
+}
+
+// fetcherState holds state for the fetcher worker
+type fetcherState struct {
+	sessionKey      int
+	lastFetchedTime time.Time
+	endTime         time.Time // Stop once data reaches this time, zero for no limit
+	driverNumber    int
+	stop            <-chan struct{}
+}
+
+// fetcher is the work function called by the ticker-based fetcher worker.
+// Returns true once there is no more data for the driver.
+func (s *vizF1viz) fetcher(ctx context.Context, state *fetcherState, driverChan chan Location) bool {
+	// Check buffer level
+	bufferLevel := float64(len(driverChan)) / float64(cap(driverChan))
+	if bufferLevel >= bufferLowThreshold {
+		return false
+	}
+
+	// Fetch next window, up to the end of the replay
+	if !state.endTime.IsZero() && !state.lastFetchedTime.Before(state.endTime) {
+		s.logger.Infof("Reached the end of the replay for driver %d, closing channel", state.driverNumber)
+		return true
+	}
+	endTime := state.lastFetchedTime.Add(fetchWindowDuration)
+	if !state.endTime.IsZero() && endTime.After(state.endTime) {
+		endTime = state.endTime
+	}
+	locations, err := s.fetchLocationData(ctx, state.sessionKey, state.driverNumber, state.lastFetchedTime, endTime)
+	if err != nil {
+		s.logger.Errorf("Failed to fetch location data for driver %d: %v", state.driverNumber, err)
+		// Continue - don't exit on error, just retry next tick
+		return false
+	}
+
+	if len(locations) == 0 {
+		// No more data available for this driver - the worker closes its channel
+		s.logger.Infof("No more location data available for driver %d, closing channel", state.driverNumber)
+		return true
+	}
+
+	// Telemetry is optional, so carry on without it rather than retrying
+	carData, err := fetchCarData(ctx, state.sessionKey, state.driverNumber, state.lastFetchedTime, endTime)
+	if err != nil {
+		s.logger.Debugf("Failed to fetch car data for driver %d: %v", state.driverNumber, err)
+	}
+	attachCarData(locations, carData)
+
+	// Send locations to channel
+	for _, loc := range locations {
+		select {
+		case <-ctx.Done():
+			return true
+		case <-state.stop:
+			return true
+		case driverChan <- loc:
+			// Successfully sent
 		}
+	}
+
+	// Update lastFetchedTime to the last location's time + small increment
+	// to avoid fetching the same point again (using >= in query)
+	lastLocTime, err := time.Parse(time.RFC3339, locations[len(locations)-1].Date)
+	if err == nil {
+		// Add 1ms to avoid re-fetching the last point
+		state.lastFetchedTime = lastLocTime.Add(1 * time.Millisecond)
+	} else {
+		// If parsing fails, advance by window duration
+		state.lastFetchedTime = endTime
+	}
+
+	s.logger.Debugf("Fetched %d locations for driver %d, buffer level: %.2f%%", len(locations), state.driverNumber, bufferLevel*100)
+	return false
+}
+
+// activeStreams returns a snapshot of the current driver streams, ordered by driver number
+func (s *vizF1viz) activeStreams() []*driverStream {
+	s.streamsMu.Lock()
+	defer s.streamsMu.Unlock()
 
-		s.logger.Debugf("Fetched %d locations for driver %d, buffer level: %.2f%%", len(locations), state.driverNumber, bufferLevel*100)
+	streams := make([]*driverStream, 0, len(s.streams))
+	for _, stream := range s.streams {
+		streams = append(streams, stream)
 	}
+	sort.Slice(streams, func(i, j int) bool {
+		return streams[i].driverNumber < streams[j].driverNumber
+	})
+	return streams
+}
+
+// currentPlaybackTime returns the date of the most recently rendered location data
+func (s *vizF1viz) currentPlaybackTime() time.Time {
+	s.streamsMu.Lock()
+	defer s.streamsMu.Unlock()
+	return s.playbackTime
 }
 
-// consumer continuously consumes and renders location data from all channels
+// consumer continuously consumes and renders location data from all driver streams
 func (s *vizF1viz) consumer(ctx context.Context) {
 	s.logger.Info("Consumer started, waiting for location data from all drivers...")
 
 	// Track locations per driver for trail rendering
-	locationHistories := make(map[int][]Location)
-	trailLength := 5
-
-	// Track which channels are still open
-	openChannels := make(map[int]bool)
-	for i := range s.locationChans {
-		openChannels[i] = true
-	}
-
-	// Outer loop: continue until all channels are closed
-	for len(openChannels) > 0 {
+	trails := newTrailTracker()
+	// Last known direction of travel per driver
+	headings := make(map[int]float64)
+
+	// Per-driver reference track matching and lap counting state
+	var matcher *trackMatcher
+	trackStates := make(map[int]*driverTrackState)
+	lapCounters := make(map[int]*lapCounter)
+	stints := make(map[int][]Stint)
+	var lastTrackDraw time.Time
+	// Streams that have delivered a location; new streams are only waited for when none has
+	primed := make(map[*driverStream]bool)
+
+	// Continue until every stream has closed
+	for {
 		// Check for context cancellation
 		select {
 		case <-ctx.Done():
@@ -392,71 +817,182 @@ func (s *vizF1viz) consumer(ctx context.Context) {
 		default:
 		}
 
-		// Collect one location from each open channel
+		streams := s.activeStreams()
+		if len(streams) == 0 {
+			break
+		}
+
+		// Collect one location from each stream, keyed by driver number
 		currentLocations := make(map[int]Location)
+		receive := func(stream *driverStream, location Location, ok bool) {
+			if !ok {
+				// Channel closed for this driver. A stream removed by the position tracker has already been
+				// deleted, and its driver has not run out of data.
+				s.logger.Infof("Channel closed for driver %d", stream.driverNumber)
+				s.streamsMu.Lock()
+				if s.streams[stream.driverNumber] == stream {
+					delete(s.streams, stream.driverNumber)
+					s.exhausted[stream.driverNumber] = true
+				}
+				s.streamsMu.Unlock()
+				delete(primed, stream)
+				return
+			}
+			primed[stream] = true
+			currentLocations[location.DriverNumber] = location
+			if _, ok := lapCounters[location.DriverNumber]; !ok {
+				lapCounters[location.DriverNumber] = newLapCounter(stream.laps)
+				stints[location.DriverNumber] = stream.stints
+			}
+		}
 
-		// Iterate through each channel and read from it
-		for i, ch := range s.locationChans {
-			if !openChannels[i] {
+		active := make(map[*driverStream]bool, len(streams))
+		anyPrimed := false
+		for _, stream := range streams {
+			active[stream] = true
+			anyPrimed = anyPrimed || primed[stream]
+		}
+		for stream := range primed {
+			if !active[stream] {
+				delete(primed, stream)
+			}
+		}
+		for _, stream := range streams {
+			// A stream added mid-replay fetches laps and stints before its first location, so skip it
+			// until it has one rather than stalling every other driver
+			if !primed[stream] && anyPrimed {
+				select {
+				case location, ok := <-stream.ch:
+					receive(stream, location, ok)
+				default:
+				}
 				continue
 			}
 
-			// Read from this channel (blocking)
+			// Read from this stream (blocking)
 			select {
 			case <-ctx.Done():
 				s.logger.Info("Consumer cancelled")
 				return
-			case location, ok := <-ch:
-				if !ok {
-					// Channel closed for this driver
-					s.logger.Infof("Channel closed for driver index %d", i)
-					delete(openChannels, i)
-					continue
-				}
-				currentLocations[i] = location
+			case <-stream.stop:
+				// Driver was removed from the set while we waited
+				continue
+			case location, ok := <-stream.ch:
+				receive(stream, location, ok)
 			}
 		}
 
-		// If we have locations from all open channels, render
-		if len(currentLocations) == len(openChannels) && len(currentLocations) > 0 {
-			// Record timestamps for this round
-			round := atomic.AddInt64(&s.roundCounter, 1)
-			roundTimestamp := RoundTimestamp{
-				Round:     round,
-				Timestamp: time.Now().UTC().Format(time.RFC3339),
-				Drivers:   make(map[int]DriverStamp),
+		if len(currentLocations) == 0 {
+			continue
+		}
+
+		// Record timestamps for this round
+		round := atomic.AddInt64(&s.roundCounter, 1)
+		roundTimestamp := RoundTimestamp{
+			Round:     round,
+			Timestamp: time.Now().UTC().Format(time.RFC3339),
+			Drivers:   make(map[int]DriverStamp),
+		}
+		var latest time.Time
+		for _, location := range currentLocations {
+			roundTimestamp.Drivers[location.DriverNumber] = DriverStamp{
+				DriverNumber: location.DriverNumber,
+				Timestamp:    location.Date,
 			}
-			for _, location := range currentLocations {
-				roundTimestamp.Drivers[location.DriverNumber] = DriverStamp{
-					DriverNumber: location.DriverNumber,
-					Timestamp:    location.Date,
-				}
+			if date, err := time.Parse(time.RFC3339, location.Date); err == nil && date.After(latest) {
+				latest = date
 			}
+		}
 
-			// Save timestamp data
-			s.timestampMu.Lock()
-			s.timestampData = append(s.timestampData, roundTimestamp)
-			s.timestampMu.Unlock()
-
-			// Update histories for all drivers
-			for _, location := range currentLocations {
-				history := locationHistories[location.DriverNumber]
-				history = append(history, location)
-				if len(history) > trailLength {
-					history = history[len(history)-trailLength:]
+		// Save timestamp data
+		s.timestampMu.Lock()
+		s.timestampData = append(s.timestampData, roundTimestamp)
+		s.timestampMu.Unlock()
+
+		// Place every driver on the reference track, rebuilding the matcher if the track changed
+		if track := s.currentReferenceTrack(); track == nil {
+			matcher = nil
+		} else if matcher == nil || matcher.track != track {
+			matcher = newTrackMatcher(track)
+			trackStates = make(map[int]*driverTrackState)
+			s.setSectorTiming(newSectorTiming(track, matcher.loopLen))
+			for _, counter := range lapCounters {
+				counter.reset()
+			}
+		}
+		driverStates := make(map[int]DriverState, len(currentLocations))
+		for driverNumber, location := range currentLocations {
+			location.TrackIndex = unknownTrackIndex
+			driverState := DriverState{DriverNumber: driverNumber}
+			if matcher != nil {
+				state, ok := trackStates[driverNumber]
+				if !ok {
+					state = &driverTrackState{}
+					trackStates[driverNumber] = state
 				}
-				locationHistories[location.DriverNumber] = history
+				location.TrackIndex = matcher.match(location, state)
+				if state.inPit {
+					driverState.InPitLane = true
+					driverState.PitProgress = state.pitProgress
+				}
+
+				date, _ := time.Parse(time.RFC3339, location.Date)
+				counter := lapCounters[driverNumber]
+				driverState.RaceDistance = counter.update(location.TrackIndex, matcher.loopLen, date)
+				driverState.Lap = counter.completed + 1
+				driverState.Compound, driverState.TyreAge = tyreOnLap(stints[driverNumber], driverState.Lap)
+				s.currentSectorTiming().record(driverNumber, driverState.RaceDistance, date)
 			}
+			currentLocations[driverNumber] = location
+			driverState.Location = location
+			driverState.TrackIndex = location.TrackIndex
+			if location.CarData != nil {
+				driverState.Speed = location.CarData.Speed
+			}
+			if history := trails.histories[driverNumber]; len(history) > 0 {
+				if heading, ok := headingBetween(history[len(history)-1], location); ok {
+					headings[driverNumber] = heading
+				}
+			}
+			driverState.Heading = headings[driverNumber]
+			driverStates[driverNumber] = driverState
+		}
+		s.updateDriverStates(driverStates, latest)
+		s.recordPaths(currentLocations)
 
-			// Render all locations as one pointcloud
-			if err := s.renderLocations(currentLocations, locationHistories); err != nil {
-				s.logger.Errorf("Failed to render locations: %v", err)
-				// Continue rendering even if one fails
+		// Recolour the track when mini-sector statuses change
+		if timing := s.currentSectorTiming(); timing != nil && time.Since(lastTrackDraw) > sectorRedrawInterval && timing.takeChanged() {
+			if err := s.drawReferenceTrack(); err != nil {
+				s.logger.Debugf("Failed to redraw reference track: %v", err)
 			}
+			lastTrackDraw = time.Now()
+		}
 
-			// Small delay to control render rate
-			time.Sleep(10 * time.Millisecond)
+		// Advance playback time so the position tracker knows where the replay is
+		if !latest.IsZero() {
+			s.streamsMu.Lock()
+			s.playbackTime = latest
+			s.streamsMu.Unlock()
 		}
+
+		// Update histories for all drivers, dropping drivers that are no longer streamed
+		for driverNumber := range trails.histories {
+			if _, ok := currentLocations[driverNumber]; !ok {
+				trails.remove(driverNumber)
+				delete(headings, driverNumber)
+				delete(trackStates, driverNumber)
+				delete(lapCounters, driverNumber)
+				delete(stints, driverNumber)
+			}
+		}
+		for _, location := range currentLocations {
+			trails.update(location, driverStates[location.DriverNumber].Lap, s.trailSettingsFor(location.DriverNumber))
+		}
+
+		s.renderFrame(ctx, trails.histories, trails.ghosts)
+
+		// Small delay to control render rate
+		time.Sleep(10 * time.Millisecond)
 	}
 
 	s.logger.Info("All channels closed, consumer stopping")
@@ -464,37 +1000,14 @@ func (s *vizF1viz) consumer(ctx context.Context) {
 
 // fetchSession fetches session information from OpenF1 API
 func (s *vizF1viz) fetchSession(ctx context.Context) (Session, error) {
-	baseURL := "https://api.openf1.org/v1/sessions"
-	u, err := url.Parse(baseURL)
-	if err != nil {
-		return Session{}, fmt.Errorf("failed to parse URL: %w", err)
-	}
-
-	q := u.Query()
+	q := url.Values{}
 	q.Set("circuit_key", fmt.Sprintf("%d", circuitKey))
 	q.Set("session_name", sessionName)
 	q.Set("year", "2023")
-	u.RawQuery = q.Encode()
-
-	req, err := http.NewRequestWithContext(ctx, "GET", u.String(), nil)
-	if err != nil {
-		return Session{}, fmt.Errorf("failed to create request: %w", err)
-	}
-
-	resp, err := http.DefaultClient.Do(req)
-	if err != nil {
-		return Session{}, fmt.Errorf("failed to make request: %w", err)
-	}
-	defer resp.Body.Close()
-
-	body, err := io.ReadAll(resp.Body)
-	if err != nil {
-		return Session{}, fmt.Errorf("failed to read response: %w", err)
-	}
 
 	var sessions []Session
-	if err := json.Unmarshal(body, &sessions); err != nil {
-		return Session{}, fmt.Errorf("failed to parse sessions: %w", err)
+	if err := fetchOpenF1(ctx, "sessions", q.Encode(), &sessions); err != nil {
+		return Session{}, err
 	}
 
 	if len(sessions) == 0 {
@@ -506,12 +1019,6 @@ func (s *vizF1viz) fetchSession(ctx context.Context) (Session, error) {
 
 // fetchLocationData fetches location data for a given time window
 func (s *vizF1viz) fetchLocationData(ctx context.Context, sessionKey, driverNumber int, startTime, endTime time.Time) ([]Location, error) {
-	locationURL := "https://api.openf1.org/v1/location"
-	u, err := url.Parse(locationURL)
-	if err != nil {
-		return nil, fmt.Errorf("failed to parse location URL: %w", err)
-	}
-
 	// Format times for API (OpenF1 expects format: 2006-01-02T15:04:05.000)
 	startTimeStr := startTime.UTC().Format("2006-01-02T15:04:05.000")
 	endTimeStr := endTime.UTC().Format("2006-01-02T15:04:05.000")
@@ -523,97 +1030,41 @@ func (s *vizF1viz) fetchLocationData(ctx context.Context, sessionKey, driverNumb
 	// Use date< for end to exclude boundary (matches OpenF1 API format)
 	queryString := fmt.Sprintf("session_key=%d&driver_number=%d&date>=%s&date<%s",
 		sessionKey, driverNumber, startEncoded, endEncoded)
-	u.RawQuery = queryString
-
-	req, err := http.NewRequestWithContext(ctx, "GET", u.String(), nil)
-	if err != nil {
-		return nil, fmt.Errorf("failed to create request: %w", err)
-	}
-
-	resp, err := http.DefaultClient.Do(req)
-	if err != nil {
-		return nil, fmt.Errorf("failed to make request: %w", err)
-	}
-	defer resp.Body.Close()
-
-	body, err := io.ReadAll(resp.Body)
-	if err != nil {
-		return nil, fmt.Errorf("failed to read response: %w", err)
-	}
 
 	var locations []Location
-	if err := json.Unmarshal(body, &locations); err != nil {
-		return nil, fmt.Errorf("failed to parse locations: %w", err)
+	if err := fetchOpenF1(ctx, "location", queryString, &locations); err != nil {
+		return nil, err
 	}
 
 	return locations, nil
 }
 
-// renderLocations renders locations from all drivers as one pointcloud
-func (s *vizF1viz) renderLocations(currentLocations map[int]Location, locationHistories map[int][]Location) error {
-	pc := pointcloud.NewBasicEmpty()
-
-	// Render each driver's current location and trail
-	for _, location := range currentLocations {
-		history := locationHistories[location.DriverNumber]
-
-		// Render trail with fading intensity
-		for i, loc := range history {
-			// Calculate fade factor: most recent is 1.0, oldest fades to 0.0
-			fadeFactor := float64(i) / float64(len(history)-1)
-			if len(history) == 1 {
-				fadeFactor = 1.0
-			}
-
-			// Color: different bright colors per driver, with fading
-			// Predefined palette of distinct bright colors for up to 10 drivers
-			driverColors := [][]uint8{
-				{255, 0, 0},     // Red
-				{0, 255, 0},     // Green
-				{0, 0, 255},     // Blue
-				{255, 255, 0},   // Yellow
-				{255, 0, 255},   // Magenta
-				{0, 255, 255},   // Cyan
-				{255, 128, 0},   // Orange
-				{128, 0, 255},   // Purple
-				{255, 192, 203}, // Pink
-				{0, 255, 128},   // Spring Green
-			}
-
-			driverIdx := location.DriverNumber % 10
-			baseColor := driverColors[driverIdx]
-
-			// Apply fade factor to make trail fade
-			r := uint8(float64(baseColor[0]) * fadeFactor)
-			g := uint8(float64(baseColor[1]) * fadeFactor)
-			b := uint8(float64(baseColor[2]) * fadeFactor)
-
-			pc.Set(r3.Vector{
-				X: float64(loc.X),
-				Y: float64(loc.Y),
-				Z: float64(loc.Z),
-			}, pointcloud.NewColoredData(color.NRGBA{R: r, G: g, B: b, A: 255}))
-		}
-	}
-
-	// Log rendering info
-	driverNums := make([]int, 0, len(currentLocations))
-	for _, loc := range currentLocations {
-		driverNums = append(driverNums, loc.DriverNumber)
-	}
-	s.logger.Debugf("Rendering pointcloud with %d drivers: %v", len(currentLocations), driverNums)
-
-	// Render the complete pointcloud
-	vizClient.DrawPointCloud("movement", pc, nil)
+// driverColors is a predefined palette of distinct bright colors for up to 10 drivers
+var driverColors = []color.NRGBA{
+	{R: 255, G: 0, B: 0, A: 255},     // Red
+	{R: 0, G: 255, B: 0, A: 255},     // Green
+	{R: 0, G: 0, B: 255, A: 255},     // Blue
+	{R: 255, G: 255, B: 0, A: 255},   // Yellow
+	{R: 255, G: 0, B: 255, A: 255},   // Magenta
+	{R: 0, G: 255, B: 255, A: 255},   // Cyan
+	{R: 255, G: 128, B: 0, A: 255},   // Orange
+	{R: 128, G: 0, B: 255, A: 255},   // Purple
+	{R: 255, G: 192, B: 203, A: 255}, // Pink
+	{R: 0, G: 255, B: 128, A: 255},   // Spring Green
+}
 
-	return nil
+// driverColor returns a driver's colour from the palette
+func driverColor(driverNumber int) color.NRGBA {
+	return driverColors[driverNumber%len(driverColors)]
 }
 
-func (s *vizF1viz) Close(context.Context) error {
+func (s *vizF1viz) Close(ctx context.Context) error {
+	unregisterLocalService(s)
 	s.cancelFunc()
 	if s.workers != nil {
 		s.workers.Stop()
 	}
+	s.closeRenderers(ctx)
 	// Write timestamps to disk on close
 	if err := s.writeTimestampsToDisk(); err != nil {
 		s.logger.Errorf("Failed to write timestamps to disk on close: %v", err)
@@ -657,42 +1108,3 @@ func (s *vizF1viz) writeTimestampsToDisk() error {
 
 	return nil
 }
-
-// loadReferenceTrack loads a reference track from a JSON file
-func loadReferenceTrack() (ReferenceTrack, error) {
-	data, err := os.ReadFile(referenceTrackFile)
-	if err != nil {
-		return ReferenceTrack{}, err
-	}
-
-	var track ReferenceTrack
-	err = json.Unmarshal(data, &track)
-	if err != nil {
-		return ReferenceTrack{}, err
-	}
-
-	if len(track.Points) != 144 {
-		return ReferenceTrack{}, fmt.Errorf("reference track must have exactly 144 points, got %d", len(track.Points))
-	}
-
-	return track, nil
-}
-
-func (s *vizF1viz) drawReferenceTrack() error {
-	pc := pointcloud.NewBasicEmpty()
-
-	// Draw all 144 points from the reference track
-	for i, point := range s.referenceTrack.Points {
-		// Color based on index: gradient from blue (0) to red (143)
-		r := uint8((i * 255) / 143)
-		b := uint8(255 - (i * 255 / 143))
-
-		pc.Set(r3.Vector{
-			X: float64(point.X),
-			Y: float64(point.Y),
-			Z: float64(point.Z),
-		}, pointcloud.NewColoredData(color.NRGBA{R: r, G: 0, B: b, A: 255}))
-	}
-
-	return vizClient.DrawPointCloud("reference", pc, nil)
-}
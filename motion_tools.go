@@ -0,0 +1,247 @@
+package f1viz
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/golang/geo/r3"
+	vizClient "github.com/viam-labs/motion-tools/client/client"
+	"go.viam.com/rdk/logging"
+	"go.viam.com/rdk/pointcloud"
+	"go.viam.com/rdk/spatialmath"
+)
+
+// motionToolsRenderer draws the replay in the motion-tools visualizer
+type motionToolsRenderer struct {
+	cfg    *Config
+	logger logging.Logger
+
+	// Labels of the track objects drawn last, so they can be replaced
+	trackLabels []string
+	// Driver labels and leaderboard
+	overlay overlay
+	// Start date of each driver's drawn lap ghost, so ghosts are only redrawn when they change
+	ghosts map[int]string
+	// Car boxes currently drawn
+	cars map[string]bool
+	// When the chase camera last moved
+	lastChase time.Time
+}
+
+// ghostBrightness is how much of a driver's colour their lap ghost keeps
+const ghostBrightness = 0.35
+
+// newMotionToolsRenderer returns a renderer that draws in the motion-tools visualizer
+func newMotionToolsRenderer(cfg *Config, logger logging.Logger) *motionToolsRenderer {
+	return &motionToolsRenderer{cfg: cfg, logger: logger}
+}
+
+// DrawTrack draws the reference track in the configured style, replacing whatever was drawn for it before
+func (r *motionToolsRenderer) DrawTrack(ctx context.Context, scene TrackScene) error {
+	track := scene.Track
+	transform := newSceneTransform(r.cfg.Transform, track)
+
+	var labels []string
+	var err error
+	switch style := r.cfg.trackStyle(); style {
+	case trackStylePoints:
+		labels, err = r.drawTrackPoints(scene, transform)
+	case trackStyleLine:
+		labels, err = r.drawTrackLine(scene, transform)
+	case trackStyleRibbon:
+		labels, err = r.drawTrackRibbon(scene, transform)
+	default:
+		return fmt.Errorf("unknown track style %q", style)
+	}
+	if err != nil {
+		return err
+	}
+
+	if r.cfg.StartFinishLine {
+		label := "reference-start-finish"
+		if err := drawTrackMarker(label, track, 0, r.cfg.trackWidth(), startFinishColor, transform); err != nil {
+			return err
+		}
+		labels = append(labels, label)
+	}
+	if r.cfg.SectorMarkers {
+		sectorStarts, _ := track.sectorLayout(len(track.Points))
+		for i, index := range sectorStarts {
+			if index == 0 && r.cfg.StartFinishLine {
+				continue
+			}
+			label := fmt.Sprintf("reference-sector-%d", i+1)
+			if err := drawTrackMarker(label, track, index, r.cfg.trackWidth(), sectorMarkerColor, transform); err != nil {
+				return err
+			}
+			labels = append(labels, label)
+		}
+	}
+
+	if track.PitLane != nil {
+		label := "reference-pit-lane"
+		poses := make([]spatialmath.Pose, 0, len(track.PitLane.Points))
+		for _, p := range track.PitLane.Points {
+			poses = append(poses, spatialmath.NewPoseFromPoint(transform.apply(trackVector(p))))
+		}
+		if err := vizClient.DrawLine(label, poses, &pitLaneColor, nil); err != nil {
+			return err
+		}
+		labels = append(labels, label)
+	}
+
+	// Remove anything left over from a previous drawing in a different style or layout
+	r.removeStaleLabels(labels)
+
+	if r.cfg.showLeaderboard() {
+		if err := r.overlay.clearLeaderboard(); err != nil {
+			r.logger.Debugf("Failed to remove old leaderboard: %v", err)
+		}
+		r.overlay.placeLeaderboard(track, r.cfg.trackWidth(), transform)
+	}
+	return nil
+}
+
+// removeStaleLabels removes previously drawn track objects that are not in labels and remembers labels
+func (r *motionToolsRenderer) removeStaleLabels(labels []string) {
+	current := make(map[string]bool, len(labels))
+	for _, label := range labels {
+		current[label] = true
+	}
+	var stale []string
+	for _, label := range r.trackLabels {
+		if !current[label] {
+			stale = append(stale, label)
+		}
+	}
+	if len(stale) > 0 {
+		if err := vizClient.RemoveSpatialObjects(stale); err != nil {
+			r.logger.Debugf("Failed to remove old track objects: %v", err)
+		}
+	}
+	r.trackLabels = labels
+}
+
+// DrawFrame renders every driver's trail as one point cloud, plus their lap ghosts and cars
+func (r *motionToolsRenderer) DrawFrame(ctx context.Context, frame Frame) error {
+	pc := pointcloud.NewBasicEmpty()
+	transform := newSceneTransform(r.cfg.Transform, frame.Track)
+
+	// Render each driver's trail in the colours the service chose
+	for _, driver := range frame.Drivers {
+		colors := frame.TrailColors[driver.DriverNumber]
+		for i, loc := range frame.Trails[driver.DriverNumber] {
+			if err := pc.Set(transform.apply(r3.Vector{
+				X: float64(loc.X),
+				Y: float64(loc.Y),
+				Z: float64(loc.Z),
+			}), pointcloud.NewColoredData(colors[i])); err != nil {
+				return err
+			}
+		}
+	}
+
+	// Render the complete pointcloud
+	if err := vizClient.DrawPointCloud("movement", pc, nil); err != nil {
+		return err
+	}
+	if err := r.drawGhosts(frame, transform); err != nil {
+		return err
+	}
+	if r.cfg.carStyle() == carStyleBox {
+		if err := r.drawCars(frame, transform); err != nil {
+			return err
+		}
+	}
+
+	// A camera that cannot be moved should not stop the labels and leaderboard being drawn
+	if err := r.followFocus(frame, transform); err != nil {
+		r.logger.Debugf("Failed to move the chase camera: %v", err)
+	}
+
+	if r.cfg.showLabels() {
+		if err := r.overlay.drawLabels(frame, r.cfg.trackWidth(), transform); err != nil {
+			return err
+		}
+	}
+	if r.cfg.showLeaderboard() {
+		return r.overlay.drawLeaderboard(frame, r.cfg.trackWidth(), transform.scale)
+	}
+	return nil
+}
+
+// drawGhosts draws each driver's last complete lap as a dim line, removing ghosts that are no longer shown
+func (r *motionToolsRenderer) drawGhosts(frame Frame, transform sceneTransform) error {
+	var stale []string
+	for driverNumber := range r.ghosts {
+		if _, ok := frame.Ghosts[driverNumber]; !ok {
+			stale = append(stale, ghostLabel(driverNumber))
+			delete(r.ghosts, driverNumber)
+		}
+	}
+	if len(stale) > 0 {
+		if err := vizClient.RemoveSpatialObjects(stale); err != nil {
+			return err
+		}
+	}
+
+	for driverNumber, ghost := range frame.Ghosts {
+		if r.ghosts[driverNumber] == ghost[0].Date {
+			continue
+		}
+		poses := make([]spatialmath.Pose, 0, len(ghost))
+		for _, loc := range ghost {
+			poses = append(poses, spatialmath.NewPoseFromPoint(transform.apply(r3.Vector{
+				X: float64(loc.X),
+				Y: float64(loc.Y),
+				Z: float64(loc.Z),
+			})))
+		}
+		c := scaleColor(frame.Colors[driverNumber], ghostBrightness)
+		lineColor := [3]uint8{c.R, c.G, c.B}
+		if err := vizClient.DrawLine(ghostLabel(driverNumber), poses, &lineColor, nil); err != nil {
+			return err
+		}
+		if r.ghosts == nil {
+			r.ghosts = make(map[int]string)
+		}
+		r.ghosts[driverNumber] = ghost[0].Date
+	}
+	return nil
+}
+
+// clearGhosts removes every drawn lap ghost
+func (r *motionToolsRenderer) clearGhosts() error {
+	if len(r.ghosts) == 0 {
+		return nil
+	}
+	labels := make([]string, 0, len(r.ghosts))
+	for driverNumber := range r.ghosts {
+		labels = append(labels, ghostLabel(driverNumber))
+	}
+	r.ghosts = nil
+	return vizClient.RemoveSpatialObjects(labels)
+}
+
+// ghostLabel is the visualizer label of a driver's lap ghost
+func ghostLabel(driverNumber int) string {
+	return fmt.Sprintf("ghost-%d", driverNumber)
+}
+
+// Clear removes the drivers' point cloud, lap ghosts, cars, labels and leaderboard
+func (r *motionToolsRenderer) Clear(ctx context.Context) error {
+	return errors.Join(
+		vizClient.RemoveSpatialObjects([]string{"movement"}),
+		r.clearGhosts(),
+		r.clearCars(),
+		r.overlay.clearLabels(),
+		r.overlay.clearLeaderboard(),
+	)
+}
+
+// Close leaves the drawing in place so the last frame stays visible in the visualizer
+func (r *motionToolsRenderer) Close(ctx context.Context) error {
+	return nil
+}
@@ -0,0 +1,163 @@
+package f1viz
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"image/color"
+	"io"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const (
+	openF1BaseURL = "https://api.openf1.org/v1/"
+	// openF1Timeout bounds each OpenF1 request so a stalled connection can't hang a fetcher
+	openF1Timeout = 30 * time.Second
+	// maxErrorBody is how much of an error response is included in the error
+	maxErrorBody = 200
+)
+
+// openF1Client is the HTTP client used for every OpenF1 request
+var openF1Client = &http.Client{Timeout: openF1Timeout}
+
+// Lap represents a lap data point from the OpenF1 API
+type Lap struct {
+	DriverNumber int      `json:"driver_number"`
+	LapNumber    int      `json:"lap_number"`
+	DateStart    string   `json:"date_start"`
+	LapDuration  *float64 `json:"lap_duration"` // Null for laps without a valid time
+	IsPitOutLap  bool     `json:"is_pit_out_lap"`
+	SessionKey   int      `json:"session_key"`
+}
+
+// Driver represents a driver entry from the OpenF1 API
+type Driver struct {
+	DriverNumber int    `json:"driver_number"`
+	NameAcronym  string `json:"name_acronym"`
+	FullName     string `json:"full_name"`
+	TeamName     string `json:"team_name"`
+	TeamColour   string `json:"team_colour"` // Hex RGB without a leading #, e.g. "3671C6"
+	SessionKey   int    `json:"session_key"`
+}
+
+// fetchOpenF1 makes a GET request against an OpenF1 endpoint and decodes the JSON response into out.
+// rawQuery is used as-is so callers can pass operators such as date>=.
+func fetchOpenF1(ctx context.Context, endpoint, rawQuery string, out interface{}) error {
+	u, err := url.Parse(openF1BaseURL + endpoint)
+	if err != nil {
+		return fmt.Errorf("failed to parse %s URL: %w", endpoint, err)
+	}
+	u.RawQuery = rawQuery
+
+	req, err := http.NewRequestWithContext(ctx, "GET", u.String(), nil)
+	if err != nil {
+		return fmt.Errorf("failed to create request: %w", err)
+	}
+
+	resp, err := openF1Client.Do(req)
+	if err != nil {
+		return fmt.Errorf("failed to make request: %w", err)
+	}
+	defer resp.Body.Close()
+
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return fmt.Errorf("failed to read response: %w", err)
+	}
+	if resp.StatusCode != http.StatusOK {
+		if len(body) > maxErrorBody {
+			body = body[:maxErrorBody]
+		}
+		return fmt.Errorf("%s returned %s: %s", endpoint, resp.Status, strings.TrimSpace(string(body)))
+	}
+
+	if err := json.Unmarshal(body, out); err != nil {
+		return fmt.Errorf("failed to parse %s: %w", endpoint, err)
+	}
+	return nil
+}
+
+// fetchSessionByKey fetches a single session from the OpenF1 API
+func fetchSessionByKey(ctx context.Context, sessionKey int) (Session, error) {
+	var sessions []Session
+	if err := fetchOpenF1(ctx, "sessions", fmt.Sprintf("session_key=%d", sessionKey), &sessions); err != nil {
+		return Session{}, err
+	}
+	if len(sessions) == 0 {
+		return Session{}, fmt.Errorf("session %d not found", sessionKey)
+	}
+	return sessions[0], nil
+}
+
+// fetchLaps fetches all laps for a driver in a session, ordered by lap number
+func fetchLaps(ctx context.Context, sessionKey, driverNumber int) ([]Lap, error) {
+	var laps []Lap
+	query := fmt.Sprintf("session_key=%d&driver_number=%d", sessionKey, driverNumber)
+	if err := fetchOpenF1(ctx, "laps", query, &laps); err != nil {
+		return nil, err
+	}
+	return laps, nil
+}
+
+// fetchDrivers fetches every driver entered in a session
+func fetchDrivers(ctx context.Context, sessionKey int) ([]Driver, error) {
+	var drivers []Driver
+	if err := fetchOpenF1(ctx, "drivers", fmt.Sprintf("session_key=%d", sessionKey), &drivers); err != nil {
+		return nil, err
+	}
+	return drivers, nil
+}
+
+// parseTeamColour parses an OpenF1 team colour such as "3671C6"
+func parseTeamColour(hex string) (color.NRGBA, error) {
+	hex = strings.TrimPrefix(hex, "#")
+	if len(hex) != 6 {
+		return color.NRGBA{}, fmt.Errorf("invalid team colour %q", hex)
+	}
+	rgb, err := strconv.ParseUint(hex, 16, 32)
+	if err != nil {
+		return color.NRGBA{}, fmt.Errorf("invalid team colour %q: %w", hex, err)
+	}
+	return color.NRGBA{R: uint8(rgb >> 16), G: uint8(rgb >> 8), B: uint8(rgb), A: 255}, nil
+}
+
+// lapWindow returns the start and end time of a lap. The end is the start of the following lap
+// when known, falling back to the lap's own duration.
+func lapWindow(laps []Lap, lapNumber int) (time.Time, time.Time, error) {
+	var lap, next *Lap
+	for i := range laps {
+		switch laps[i].LapNumber {
+		case lapNumber:
+			lap = &laps[i]
+		case lapNumber + 1:
+			next = &laps[i]
+		}
+	}
+	if lap == nil {
+		return time.Time{}, time.Time{}, fmt.Errorf("lap %d not found", lapNumber)
+	}
+	if lap.DateStart == "" {
+		return time.Time{}, time.Time{}, fmt.Errorf("lap %d has no start time", lapNumber)
+	}
+
+	start, err := time.Parse(time.RFC3339, lap.DateStart)
+	if err != nil {
+		return time.Time{}, time.Time{}, fmt.Errorf("failed to parse lap %d start time: %w", lapNumber, err)
+	}
+
+	if next != nil && next.DateStart != "" {
+		end, err := time.Parse(time.RFC3339, next.DateStart)
+		if err == nil {
+			return start, end, nil
+		}
+	}
+	if lap.LapDuration == nil {
+		return time.Time{}, time.Time{}, fmt.Errorf("lap %d has no duration", lapNumber)
+	}
+	end := start.Add(time.Duration(*lap.LapDuration * float64(time.Second)))
+	return start, end, nil
+}
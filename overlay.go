@@ -0,0 +1,179 @@
+package f1viz
+
+import (
+	"fmt"
+	"math"
+
+	"github.com/golang/geo/r3"
+	vizClient "github.com/viam-labs/motion-tools/client/client"
+	"go.viam.com/rdk/referenceframe"
+	"go.viam.com/rdk/spatialmath"
+)
+
+const (
+	// labelHeightRatio is how far above each car its label is drawn, in track widths
+	labelHeightRatio = 2.0
+	// leaderboardRowRatio is the spacing between leaderboard rows, in track widths
+	leaderboardRowRatio = 2.0
+	// leaderboardGapRatio is the space between the track and the leaderboard, in track widths
+	leaderboardGapRatio = 4.0
+)
+
+// overlay is the driver labels and leaderboard drawn by the motion-tools renderer
+type overlay struct {
+	// Top left of the leaderboard and the distance between rows, in scene coordinates
+	boardOrigin r3.Vector
+	boardRow    float64
+	hasBoard    bool
+
+	carLabels   map[string]bool // Driver labels currently drawn
+	boardLabels []string        // Leaderboard rows currently drawn, in order
+}
+
+// showLabels reports whether driver labels are drawn
+func (cfg *Config) showLabels() bool {
+	return !cfg.HideLabels
+}
+
+// showLeaderboard reports whether the leaderboard is drawn
+func (cfg *Config) showLeaderboard() bool {
+	return !cfg.HideLeaderboard
+}
+
+// placeLeaderboard positions the leaderboard to the right of the track
+func (o *overlay) placeLeaderboard(track *ReferenceTrack, width float64, transform sceneTransform) {
+	maxX, maxY := -math.MaxFloat64, -math.MaxFloat64
+	for _, p := range track.Points {
+		v := transform.apply(trackVector(p))
+		maxX, maxY = math.Max(maxX, v.X), math.Max(maxY, v.Y)
+	}
+	o.boardRow = width * leaderboardRowRatio * transform.scale
+	o.boardOrigin = r3.Vector{X: maxX + width*leaderboardGapRatio*transform.scale, Y: maxY}
+	o.hasBoard = true
+	// Rows are redrawn in their new place on the next frame
+	o.boardLabels = nil
+}
+
+// drawLabels draws a labelled marker above every driver and removes the markers of drivers no longer in the frame
+func (o *overlay) drawLabels(frame Frame, width float64, transform sceneTransform) error {
+	labels := make(map[string]bool, len(frame.Drivers))
+	geometries := make([]spatialmath.Geometry, 0, len(frame.Drivers))
+	colors := make([]string, 0, len(frame.Drivers))
+	for _, driver := range frame.Drivers {
+		label := driverLabel(frame, driver.DriverNumber)
+		above := r3.Vector{X: float64(driver.Location.X), Y: float64(driver.Location.Y), Z: float64(driver.Location.Z) + width*labelHeightRatio}
+		sphere, err := spatialmath.NewSphere(spatialmath.NewPoseFromPoint(transform.apply(above)), width/3*transform.scale, label)
+		if err != nil {
+			return err
+		}
+		geometries = append(geometries, sphere)
+		colors = append(colors, hexColor(frame.Colors[driver.DriverNumber]))
+		labels[label] = true
+	}
+
+	var stale []string
+	for label := range o.carLabels {
+		if !labels[label] {
+			stale = append(stale, label)
+		}
+	}
+	if len(stale) > 0 {
+		if err := vizClient.RemoveSpatialObjects(stale); err != nil {
+			return err
+		}
+	}
+	o.carLabels = labels
+
+	if len(geometries) == 0 {
+		return nil
+	}
+	return vizClient.DrawGeometries(referenceframe.NewGeometriesInFrame(referenceframe.World, geometries), colors)
+}
+
+// drawLeaderboard draws one labelled row per driver in running order, redrawing only when the order changes
+func (o *overlay) drawLeaderboard(frame Frame, width float64, scale float64) error {
+	if !o.hasBoard {
+		return nil
+	}
+	labels := make([]string, len(frame.Drivers))
+	for i, driver := range frame.Drivers {
+		// Followed drivers keep their race position; only fall back to the row when it is unknown
+		position := driver.Position
+		if position == 0 {
+			position = i + 1
+		}
+		labels[i] = fmt.Sprintf("P%d %s", position, driverLabel(frame, driver.DriverNumber))
+	}
+	if equalStrings(labels, o.boardLabels) {
+		return nil
+	}
+	if err := o.clearLeaderboard(); err != nil {
+		return err
+	}
+	if len(labels) == 0 {
+		return nil
+	}
+
+	dims := r3.Vector{X: width * 6 * scale, Y: width * 1.5 * scale, Z: width / 2 * scale}
+	geometries := make([]spatialmath.Geometry, len(labels))
+	colors := make([]string, len(labels))
+	for i, driver := range frame.Drivers {
+		center := o.boardOrigin.Add(r3.Vector{X: dims.X / 2, Y: -float64(i) * o.boardRow})
+		box, err := spatialmath.NewBox(spatialmath.NewPoseFromPoint(center), dims, labels[i])
+		if err != nil {
+			return err
+		}
+		geometries[i] = box
+		colors[i] = hexColor(frame.Colors[driver.DriverNumber])
+	}
+	if err := vizClient.DrawGeometries(referenceframe.NewGeometriesInFrame(referenceframe.World, geometries), colors); err != nil {
+		return err
+	}
+	o.boardLabels = labels
+	return nil
+}
+
+// clearLabels removes every driver label
+func (o *overlay) clearLabels() error {
+	if len(o.carLabels) == 0 {
+		return nil
+	}
+	labels := make([]string, 0, len(o.carLabels))
+	for label := range o.carLabels {
+		labels = append(labels, label)
+	}
+	o.carLabels = nil
+	return vizClient.RemoveSpatialObjects(labels)
+}
+
+// clearLeaderboard removes every leaderboard row
+func (o *overlay) clearLeaderboard() error {
+	if len(o.boardLabels) == 0 {
+		return nil
+	}
+	labels := o.boardLabels
+	o.boardLabels = nil
+	return vizClient.RemoveSpatialObjects(labels)
+}
+
+// driverLabel is the label shown for a driver: their acronym and number, e.g. "VER 1"
+func driverLabel(frame Frame, driverNumber int) string {
+	acronym := frame.Acronyms[driverNumber]
+	if acronym == "" || acronym == fmt.Sprint(driverNumber) {
+		return fmt.Sprintf("#%d", driverNumber)
+	}
+	return fmt.Sprintf("%s %d", acronym, driverNumber)
+}
+
+// equalStrings reports whether two string slices are identical
+func equalStrings(a, b []string) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for i := range a {
+		if a[i] != b[i] {
+			return false
+		}
+	}
+	return true
+}
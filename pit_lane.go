@@ -0,0 +1,223 @@
+package f1viz
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"time"
+
+	"github.com/golang/geo/r3"
+)
+
+const (
+	// pitLaneMinOffset is how far, in OpenF1 units, a location must be from the main track to count as off it
+	pitLaneMinOffset = 120.0
+	// minPitLaneSamples is the fewest consecutive off-track locations accepted as a pit lane
+	minPitLaneSamples = 5
+	// pitWindowMargin is how much location data either side of a pit stop is searched for the pit lane
+	pitWindowMargin = 20 * time.Second
+	// pitEntryWindowSegments is how far from the pit entry, in track indices before or after it, a car may be to
+	// enter the pit lane. The pit lane runs beside the main track there, so a car can be matched past the entry.
+	pitEntryWindowSegments = 4
+)
+
+// PitLane is a polyline for the pit lane, leaving the main track at EntryIndex and rejoining at ExitIndex
+type PitLane struct {
+	EntryIndex int          `json:"entry_index"`
+	ExitIndex  int          `json:"exit_index"`
+	Points     []TrackPoint `json:"points"` // From the pit entry to the pit exit
+}
+
+// PitStop represents a pit stop from the OpenF1 API
+type PitStop struct {
+	Date         string   `json:"date"`
+	DriverNumber int      `json:"driver_number"`
+	LapNumber    int      `json:"lap_number"`
+	PitDuration  *float64 `json:"pit_duration"` // Time spent in the pit lane in seconds
+	SessionKey   int      `json:"session_key"`
+}
+
+// fetchPitStops fetches a driver's pit stops in a session
+func fetchPitStops(ctx context.Context, sessionKey, driverNumber int) ([]PitStop, error) {
+	var stops []PitStop
+	query := fmt.Sprintf("session_key=%d&driver_number=%d", sessionKey, driverNumber)
+	if err := fetchOpenF1(ctx, "pit", query, &stops); err != nil {
+		return nil, err
+	}
+	return stops, nil
+}
+
+// validatePitLane checks that a pit lane joins the track at valid indices
+func validatePitLane(track *ReferenceTrack) error {
+	pit := track.PitLane
+	if pit == nil {
+		return nil
+	}
+	if len(pit.Points) < 2 {
+		return fmt.Errorf("pit lane must have at least 2 points, got %d", len(pit.Points))
+	}
+	n := len(track.Points)
+	if pit.EntryIndex < 0 || pit.EntryIndex >= n || pit.ExitIndex < 0 || pit.ExitIndex >= n {
+		return fmt.Errorf("pit lane entry %d and exit %d must be track indices below %d", pit.EntryIndex, pit.ExitIndex, n)
+	}
+	return nil
+}
+
+// distanceToTrack returns the 2D distance from a point to the closest segment of the track among
+// the given starting indices, and the index of the closest track point
+func distanceToTrack(x, y float64, track *ReferenceTrack, indices []int) (float64, int) {
+	n := len(track.Points)
+	best := math.MaxFloat64
+	bestIdx := unknownTrackIndex
+	for _, i := range indices {
+		d, t := segmentDistance(x, y, track.Points[i], track.Points[(i+1)%n])
+		if d < best {
+			best = d
+			bestIdx = i
+			if t > 0.5 {
+				bestIdx = (i + 1) % n
+			}
+		}
+	}
+	return best, bestIdx
+}
+
+// segmentDistance returns the 2D distance from a point to the segment from a to b, and how far along the
+// segment the closest point is, from 0 at a to 1 at b
+func segmentDistance(x, y float64, a, b TrackPoint) (float64, float64) {
+	p := r3.Vector{X: x, Y: y}
+	va := r3.Vector{X: float64(a.X), Y: float64(a.Y)}
+	ab := r3.Vector{X: float64(b.X - a.X), Y: float64(b.Y - a.Y)}
+	t := 0.0
+	if l := ab.Norm2(); l > 0 {
+		t = math.Max(0, math.Min(1, p.Sub(va).Dot(ab)/l))
+	}
+	return p.Sub(va.Add(ab.Mul(t))).Norm(), t
+}
+
+// allIndices returns 0 to n-1
+func allIndices(n int) []int {
+	indices := make([]int, n)
+	for i := range indices {
+		indices[i] = i
+	}
+	return indices
+}
+
+// extractPitLane finds the longest run of locations away from the main track and turns it into a pit lane
+func extractPitLane(locations []Location, track *ReferenceTrack) (*PitLane, error) {
+	indices := allIndices(len(track.Points))
+	nearest := make([]int, len(locations))
+	runStart, runLen := -1, 0
+	curStart := -1
+	for i, loc := range locations {
+		dist, idx := distanceToTrack(float64(loc.X), float64(loc.Y), track, indices)
+		nearest[i] = idx
+		if dist > pitLaneMinOffset {
+			if curStart < 0 {
+				curStart = i
+			}
+			if i-curStart+1 > runLen {
+				runStart, runLen = curStart, i-curStart+1
+			}
+		} else {
+			curStart = -1
+		}
+	}
+	if runLen < minPitLaneSamples {
+		return nil, fmt.Errorf("no pit lane found: longest run away from the track is %d locations", runLen)
+	}
+	if runStart == 0 || runStart+runLen >= len(locations) {
+		return nil, fmt.Errorf("pit lane runs past the end of the location data")
+	}
+
+	entry := nearest[runStart-1]
+	exit := nearest[runStart+runLen]
+	path := []TrackPoint{track.Points[entry]}
+	for _, loc := range locations[runStart : runStart+runLen] {
+		path = append(path, TrackPoint{X: loc.X, Y: loc.Y, Z: loc.Z})
+	}
+	path = append(path, track.Points[exit])
+
+	// Resample with about the same spacing as the main track
+	var length float64
+	for i := 1; i < len(path); i++ {
+		length += distance2D(path[i-1].X, path[i-1].Y, path[i].X, path[i].Y)
+	}
+	segment := newTrackMatcher(track).segment
+	points, err := resamplePath(path, max(minTrackResolution, int(math.Ceil(length/segment))+1))
+	if err != nil {
+		return nil, err
+	}
+
+	return &PitLane{EntryIndex: entry, ExitIndex: exit, Points: points}, nil
+}
+
+// buildPitLane generates a pit lane for a track from the first usable pit stop of the given drivers
+func (s *vizF1viz) buildPitLane(ctx context.Context, session Session, driverNumbers []int, track *ReferenceTrack) (*PitLane, error) {
+	for _, driverNumber := range driverNumbers {
+		stops, err := fetchPitStops(ctx, session.SessionKey, driverNumber)
+		if err != nil {
+			return nil, fmt.Errorf("failed to fetch pit stops for driver %d: %w", driverNumber, err)
+		}
+		for _, stop := range stops {
+			date, err := time.Parse(time.RFC3339, stop.Date)
+			if err != nil || stop.PitDuration == nil {
+				continue
+			}
+			end := date.Add(time.Duration(*stop.PitDuration * float64(time.Second))).Add(pitWindowMargin)
+			locations, err := s.fetchLocationData(ctx, session.SessionKey, driverNumber, date.Add(-pitWindowMargin), end)
+			if err != nil {
+				return nil, fmt.Errorf("failed to fetch pit stop location data for driver %d: %w", driverNumber, err)
+			}
+			pit, err := extractPitLane(locations, track)
+			if err != nil {
+				s.logger.Debugf("Driver %d lap %d pit stop: %v", driverNumber, stop.LapNumber, err)
+				continue
+			}
+			s.logger.Infof("Generated pit lane from driver %d's lap %d pit stop (entry %d, exit %d)",
+				driverNumber, stop.LapNumber, pit.EntryIndex, pit.ExitIndex)
+			return pit, nil
+		}
+	}
+	return nil, fmt.Errorf("no usable pit stops for drivers %v", driverNumbers)
+}
+
+// pitMatcher detects cars in the pit lane and measures their progress along it
+type pitMatcher struct {
+	pit     *PitLane
+	loopLen int
+	span    int // Main track indices from pit entry to pit exit
+}
+
+// newPitMatcher builds a pit lane matcher, or returns nil if the track has no pit lane
+func newPitMatcher(track *ReferenceTrack) *pitMatcher {
+	if track.PitLane == nil {
+		return nil
+	}
+	n := len(track.Points)
+	return &pitMatcher{
+		pit:     track.PitLane,
+		loopLen: n,
+		span:    (track.PitLane.ExitIndex - track.PitLane.EntryIndex + n) % n,
+	}
+}
+
+// nearest returns the closest position along the pit lane to a location, in pit lane points from the
+// entry, and its distance. Distance is measured to the pit lane's segments, as it is for the main track,
+// so the sparser pit lane points don't make the main track look closer between them.
+func (pm *pitMatcher) nearest(location Location) (float64, float64) {
+	best, bestPos := math.MaxFloat64, 0.0
+	for i := 0; i+1 < len(pm.pit.Points); i++ {
+		d, t := segmentDistance(float64(location.X), float64(location.Y), pm.pit.Points[i], pm.pit.Points[i+1])
+		if d < best {
+			best, bestPos = d, float64(i)+t
+		}
+	}
+	return bestPos, best
+}
+
+// trackIndex maps progress along the pit lane onto the main track between the entry and exit
+func (pm *pitMatcher) trackIndex(progress float64) int {
+	return (pm.pit.EntryIndex + int(math.Round(progress*float64(pm.span)))) % pm.loopLen
+}
@@ -0,0 +1,97 @@
+package f1viz
+
+import "testing"
+
+// pitLaneTrack is a 10000 by 4000 rectangle with a point every 500, running along y=0 first, and a pit
+// lane 400 beside that straight from index 4 (x=2000) to index 14 (x=7000)
+func pitLaneTrack() *ReferenceTrack {
+	track := &ReferenceTrack{}
+	for x := 0; x < 10000; x += 500 {
+		track.Points = append(track.Points, TrackPoint{X: x})
+	}
+	for y := 0; y < 4000; y += 500 {
+		track.Points = append(track.Points, TrackPoint{X: 10000, Y: y})
+	}
+	for x := 10000; x > 0; x -= 500 {
+		track.Points = append(track.Points, TrackPoint{X: x, Y: 4000})
+	}
+	for y := 4000; y > 0; y -= 500 {
+		track.Points = append(track.Points, TrackPoint{Y: y})
+	}
+	track.Resolution = len(track.Points)
+
+	pit := &PitLane{EntryIndex: 4, ExitIndex: 14}
+	pit.Points = append(pit.Points, TrackPoint{X: 2000}, TrackPoint{X: 2500, Y: -200})
+	for x := 3000; x <= 6000; x += 500 {
+		pit.Points = append(pit.Points, TrackPoint{X: x, Y: -400})
+	}
+	pit.Points = append(pit.Points, TrackPoint{X: 6500, Y: -200}, TrackPoint{X: 7000})
+	track.PitLane = pit
+	return track
+}
+
+func TestTrackMatcherPitLane(t *testing.T) {
+	m := newTrackMatcher(pitLaneTrack())
+
+	tests := []struct {
+		name      string
+		state     driverTrackState
+		locations []Location
+		wantInPit bool
+		wantIndex int
+	}{
+		{
+			name:      "entering the pit lane",
+			locations: []Location{{X: 1000}, {X: 1500}, {X: 2000}, {X: 2500, Y: -200}, {X: 3000, Y: -400}},
+			wantInPit: true,
+			wantIndex: 6, // A fifth of the way along a pit lane spanning ten track indices
+		},
+		{
+			name:      "entering just after the pit entry",
+			state:     driverTrackState{index: 5, previous: Location{X: 2500}, hasPrev: true},
+			locations: []Location{{X: 3000, Y: -400}},
+			wantInPit: true,
+			wantIndex: 6,
+		},
+		{
+			name:      "driving past the pit lane",
+			locations: []Location{{X: 1500}, {X: 2000}, {X: 2500}, {X: 3000}, {X: 3500}},
+			wantIndex: 7,
+		},
+		{
+			name:      "too far before the pit entry",
+			state:     driverTrackState{index: 55, previous: Location{Y: 500}, hasPrev: true},
+			locations: []Location{{X: 2500, Y: -200}},
+			wantIndex: 4,
+		},
+		{
+			name:      "far from the pit entry",
+			state:     driverTrackState{index: 40, previous: Location{X: 4000, Y: 4000}, hasPrev: true},
+			locations: []Location{{X: 4500, Y: -400}},
+			wantIndex: 9,
+		},
+		{
+			name: "leaving the pit lane",
+			locations: []Location{
+				{X: 2000}, {X: 2500, Y: -200}, {X: 4500, Y: -400}, {X: 6500, Y: -200}, {X: 7500},
+			},
+			wantIndex: 15,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			state := tt.state
+			var got int
+			for _, location := range tt.locations {
+				got = m.match(location, &state)
+			}
+			if state.inPit != tt.wantInPit {
+				t.Errorf("in pit lane = %v, want %v", state.inPit, tt.wantInPit)
+			}
+			if got != tt.wantIndex {
+				t.Errorf("track index = %d, want %d", got, tt.wantIndex)
+			}
+		})
+	}
+}
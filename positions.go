@@ -0,0 +1,154 @@
+package f1viz
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"time"
+)
+
+// positionCheckInterval is how often the position tracker re-evaluates the top N drivers
+const positionCheckInterval = 2 * time.Second
+
+// Position represents a race position data point from the OpenF1 API
+type Position struct {
+	Date         string `json:"date"`
+	DriverNumber int    `json:"driver_number"`
+	MeetingKey   int    `json:"meeting_key"`
+	SessionKey   int    `json:"session_key"`
+	Position     int    `json:"position"`
+}
+
+// positionChange is a parsed Position, ordered by date in a positionTimeline
+type positionChange struct {
+	date         time.Time
+	driverNumber int
+	position     int
+}
+
+// positionTimeline holds every position change in a session, sorted by date
+type positionTimeline struct {
+	changes []positionChange
+}
+
+// newPositionTimeline parses and sorts position data. Entries with unparseable dates are skipped.
+func newPositionTimeline(positions []Position) *positionTimeline {
+	changes := make([]positionChange, 0, len(positions))
+	for _, p := range positions {
+		date, err := time.Parse(time.RFC3339, p.Date)
+		if err != nil {
+			continue
+		}
+		changes = append(changes, positionChange{
+			date:         date,
+			driverNumber: p.DriverNumber,
+			position:     p.Position,
+		})
+	}
+	sort.SliceStable(changes, func(i, j int) bool {
+		return changes[i].date.Before(changes[j].date)
+	})
+	return &positionTimeline{changes: changes}
+}
+
+// positionsAt returns each driver's race position as of t.
+// Before the first change, the first reported position of each driver (the grid) is used.
+func (pt *positionTimeline) positionsAt(t time.Time) map[int]int {
+	positions := make(map[int]int)
+	for _, change := range pt.changes {
+		if change.date.After(t) {
+			// Fill in drivers we have not seen yet from their first entry
+			if _, ok := positions[change.driverNumber]; ok {
+				continue
+			}
+		}
+		positions[change.driverNumber] = change.position
+	}
+	return positions
+}
+
+// topN returns the driver numbers of the first n cars at time t, in running order
+func (pt *positionTimeline) topN(t time.Time, n int) []int {
+	return topDrivers(pt.positionsAt(t), n, nil)
+}
+
+// topDrivers returns up to n driver numbers ordered by position, skipping excluded drivers
+func topDrivers(positions map[int]int, n int, exclude map[int]bool) []int {
+	drivers := make([]int, 0, len(positions))
+	for driverNumber := range positions {
+		if exclude[driverNumber] {
+			continue
+		}
+		drivers = append(drivers, driverNumber)
+	}
+	sort.Slice(drivers, func(i, j int) bool {
+		pi, pj := positions[drivers[i]], positions[drivers[j]]
+		if pi != pj {
+			return pi < pj
+		}
+		return drivers[i] < drivers[j]
+	})
+	if len(drivers) > n {
+		drivers = drivers[:n]
+	}
+	return drivers
+}
+
+// positionTracker keeps the set of driver streams equal to the top N cars at the current playback time,
+// adding and removing streams as the running order changes
+func (s *vizF1viz) positionTracker(ctx context.Context, sessionKey int, positions *positionTimeline, n int) {
+	ticker := time.NewTicker(positionCheckInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+
+		playbackTime := s.currentPlaybackTime()
+
+		s.streamsMu.Lock()
+		exhausted := make(map[int]bool, len(s.exhausted))
+		for driverNumber := range s.exhausted {
+			exhausted[driverNumber] = true
+		}
+		current := make(map[int]bool, len(s.streams))
+		for driverNumber := range s.streams {
+			current[driverNumber] = true
+		}
+		s.streamsMu.Unlock()
+
+		want := topDrivers(positions.positionsAt(playbackTime), n, exhausted)
+		if len(want) == 0 {
+			// Every driver has run out of data; the consumer will stop on its own
+			return
+		}
+		wanted := make(map[int]bool, len(want))
+
+		// Add new drivers before removing old ones so the consumer never sees an empty set
+		for _, driverNumber := range want {
+			wanted[driverNumber] = true
+			if !current[driverNumber] {
+				s.logger.Infof("Driver %d moved into the top %d, adding stream", driverNumber, n)
+				s.addDriverStream(sessionKey, driverNumber, playbackTime)
+			}
+		}
+		for driverNumber := range current {
+			if !wanted[driverNumber] {
+				s.logger.Infof("Driver %d dropped out of the top %d, removing stream", driverNumber, n)
+				s.removeDriverStream(driverNumber)
+			}
+		}
+	}
+}
+
+// fetchPositionTimeline fetches all position data for a session from the OpenF1 API
+func (s *vizF1viz) fetchPositionTimeline(ctx context.Context, sessionKey int) (*positionTimeline, error) {
+	var positions []Position
+	if err := fetchOpenF1(ctx, "position", fmt.Sprintf("session_key=%d", sessionKey), &positions); err != nil {
+		return nil, err
+	}
+	return newPositionTimeline(positions), nil
+}
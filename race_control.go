@@ -0,0 +1,137 @@
+package f1viz
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+)
+
+const (
+	trackFlagGreen     = "GREEN"
+	trackFlagRed       = "RED"
+	trackFlagChequered = "CHEQUERED"
+
+	safetyCarDeployed        = "SC"
+	virtualSafetyCarDeployed = "VSC"
+)
+
+// RaceControlMessage represents a race control message from the OpenF1 API
+type RaceControlMessage struct {
+	Date         string `json:"date"`
+	Category     string `json:"category"` // e.g. Flag, SafetyCar, Drs, Other
+	Flag         string `json:"flag"`     // e.g. GREEN, YELLOW, DOUBLE YELLOW, RED, CHEQUERED, CLEAR
+	Scope        string `json:"scope"`    // Track, Sector or Driver
+	Sector       *int   `json:"sector"`
+	DriverNumber *int   `json:"driver_number"`
+	Message      string `json:"message"`
+}
+
+// FlagState is the flag situation on track at a moment in the session
+type FlagState struct {
+	Track         string `json:"track"`                    // GREEN, RED or CHEQUERED
+	SafetyCar     string `json:"safety_car,omitempty"`     // SC, VSC or empty
+	YellowSectors []int  `json:"yellow_sectors,omitempty"` // Marshal sectors under yellow flags, in order
+}
+
+// flagChange is the flag state from a time onwards
+type flagChange struct {
+	time  time.Time
+	state FlagState
+}
+
+// flagTimeline is the flag state through a session, derived from race control messages
+type flagTimeline struct {
+	changes []flagChange // Ordered by time
+}
+
+// newFlagTimeline replays race control messages into a timeline of flag states
+func newFlagTimeline(messages []RaceControlMessage) *flagTimeline {
+	sort.SliceStable(messages, func(i, j int) bool {
+		return messages[i].Date < messages[j].Date
+	})
+
+	state := FlagState{Track: trackFlagGreen}
+	yellows := make(map[int]bool)
+	timeline := &flagTimeline{}
+	for _, msg := range messages {
+		date, err := time.Parse(time.RFC3339, msg.Date)
+		if err != nil {
+			continue
+		}
+
+		switch msg.Category {
+		case "Flag":
+			switch {
+			case msg.Scope == "Track" && (msg.Flag == "GREEN" || msg.Flag == "CLEAR"):
+				state.Track = trackFlagGreen
+				state.SafetyCar = ""
+				yellows = make(map[int]bool)
+			case msg.Scope == "Track" && msg.Flag == "RED":
+				state.Track = trackFlagRed
+			case msg.Flag == "CHEQUERED":
+				state.Track = trackFlagChequered
+			case msg.Scope == "Sector" && msg.Sector != nil && strings.Contains(msg.Flag, "YELLOW"):
+				yellows[*msg.Sector] = true
+			case msg.Scope == "Sector" && msg.Sector != nil && msg.Flag == "CLEAR":
+				delete(yellows, *msg.Sector)
+			default:
+				continue
+			}
+		case "SafetyCar":
+			message := strings.ToUpper(msg.Message)
+			switch {
+			case strings.Contains(message, "VIRTUAL SAFETY CAR DEPLOYED"):
+				state.SafetyCar = virtualSafetyCarDeployed
+			case strings.Contains(message, "SAFETY CAR DEPLOYED"):
+				state.SafetyCar = safetyCarDeployed
+			case strings.Contains(message, "VIRTUAL SAFETY CAR ENDING"):
+				state.SafetyCar = ""
+			default:
+				continue
+			}
+		default:
+			continue
+		}
+
+		state.YellowSectors = make([]int, 0, len(yellows))
+		for sector := range yellows {
+			state.YellowSectors = append(state.YellowSectors, sector)
+		}
+		sort.Ints(state.YellowSectors)
+		timeline.changes = append(timeline.changes, flagChange{time: date, state: state})
+	}
+	return timeline
+}
+
+// flagAt returns the flag state at a time; green before the first message
+func (t *flagTimeline) flagAt(at time.Time) FlagState {
+	if t == nil {
+		return FlagState{Track: trackFlagGreen}
+	}
+	i := sort.Search(len(t.changes), func(i int) bool {
+		return t.changes[i].time.After(at)
+	})
+	if i == 0 {
+		return FlagState{Track: trackFlagGreen}
+	}
+	return t.changes[i-1].state
+}
+
+// fetchFlagTimeline fetches a session's race control messages and builds its flag timeline
+func fetchFlagTimeline(ctx context.Context, sessionKey int) (*flagTimeline, error) {
+	var messages []RaceControlMessage
+	if err := fetchOpenF1(ctx, "race_control", fmt.Sprintf("session_key=%d", sessionKey), &messages); err != nil {
+		return nil, err
+	}
+	return newFlagTimeline(messages), nil
+}
+
+// currentFlags returns the flag state at the current playback time
+func (s *vizF1viz) currentFlags() FlagState {
+	s.stateMu.RLock()
+	flags := s.flags
+	s.stateMu.RUnlock()
+	return flags.flagAt(s.currentPlaybackTime())
+}
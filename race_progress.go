@@ -0,0 +1,147 @@
+package f1viz
+
+import (
+	"sort"
+	"time"
+)
+
+const (
+	// positionSourceOpenF1 marks race positions reported by OpenF1
+	positionSourceOpenF1 = "openf1"
+	// positionSourceRaceDistance marks positions ranked by race distance among the followed drivers only
+	positionSourceRaceDistance = "race_distance"
+)
+
+// lapCounter turns a driver's successive track indices into continuous race distance. Start/finish
+// crossings are detected from index wrap-around, and are reconciled against OpenF1 lap start times
+// when those are available.
+type lapCounter struct {
+	lapStarts []time.Time // Start times of laps 2 onwards; each one marks a completed lap
+	completed int         // Completed laps; -1 while still behind the line before the start
+	lastIndex int
+	started   bool // Whether lastIndex is valid on the current track
+	counted   bool // Whether completed has been initialised
+}
+
+// newLapCounter creates a lap counter for a driver, using their OpenF1 laps if known
+func newLapCounter(laps []Lap) *lapCounter {
+	c := &lapCounter{}
+	for _, lap := range laps {
+		if lap.LapNumber < 2 || lap.DateStart == "" {
+			continue
+		}
+		if start, err := time.Parse(time.RFC3339, lap.DateStart); err == nil {
+			c.lapStarts = append(c.lapStarts, start)
+		}
+	}
+	sort.Slice(c.lapStarts, func(i, j int) bool {
+		return c.lapStarts[i].Before(c.lapStarts[j])
+	})
+	return c
+}
+
+// reset forgets the last track index, for when the reference track changes. The lap count is kept.
+func (c *lapCounter) reset() {
+	c.started = false
+}
+
+// lapsCompletedAt returns how many laps had been completed at t according to OpenF1, or -1 if unknown
+func (c *lapCounter) lapsCompletedAt(t time.Time) int {
+	if len(c.lapStarts) == 0 || t.IsZero() {
+		return -1
+	}
+	return sort.Search(len(c.lapStarts), func(i int) bool {
+		return c.lapStarts[i].After(t)
+	})
+}
+
+// update records a new track index and returns the driver's race distance in laps
+// (completed laps plus the fraction of the current lap)
+func (c *lapCounter) update(index, loopLen int, date time.Time) float64 {
+	official := c.lapsCompletedAt(date)
+
+	switch {
+	case !c.started:
+		c.started = true
+		if official >= 0 {
+			c.completed = official
+		} else if !c.counted {
+			// Without lap data, assume a car in the second half of the lap is on the grid behind the line
+			c.completed = 0
+			if index > loopLen/2 {
+				c.completed = -1
+			}
+		}
+		c.counted = true
+	case index < c.lastIndex && c.lastIndex-index > loopLen/2:
+		// Wrapped forwards past the start/finish line
+		c.completed++
+	case index > c.lastIndex && index-c.lastIndex > loopLen/2:
+		// Slipped backwards over the line
+		c.completed--
+	}
+
+	// Away from the line, trust OpenF1's lap count over our own crossings
+	if official >= 0 && index > loopLen/4 && index < loopLen*3/4 {
+		c.completed = official
+	}
+
+	c.lastIndex = index
+	return float64(c.completed) + float64(index)/float64(loopLen)
+}
+
+// assignRunningOrder sets each driver's Position and gap to the leader. OpenF1 race positions are used
+// when they cover every driver; otherwise the drivers are ranked among themselves by race distance,
+// leader first. Drivers without a known track index are left without a gap, and without a position
+// when ranked by race distance.
+func assignRunningOrder(states map[int]DriverState, official map[int]int) {
+	ranked := make([]int, 0, len(states))
+	var leader float64
+	for driverNumber, state := range states {
+		if state.TrackIndex != unknownTrackIndex {
+			ranked = append(ranked, driverNumber)
+			leader = max(leader, state.RaceDistance)
+		}
+	}
+	for _, driverNumber := range ranked {
+		state := states[driverNumber]
+		state.GapToLeaderLaps = leader - state.RaceDistance
+		states[driverNumber] = state
+	}
+
+	if coversDrivers(official, states) {
+		for driverNumber, state := range states {
+			state.Position = official[driverNumber]
+			state.PositionSource = positionSourceOpenF1
+			states[driverNumber] = state
+		}
+		return
+	}
+
+	sort.Slice(ranked, func(i, j int) bool {
+		di, dj := states[ranked[i]].RaceDistance, states[ranked[j]].RaceDistance
+		if di != dj {
+			return di > dj
+		}
+		return ranked[i] < ranked[j]
+	})
+	for i, driverNumber := range ranked {
+		state := states[driverNumber]
+		state.Position = i + 1
+		state.PositionSource = positionSourceRaceDistance
+		states[driverNumber] = state
+	}
+}
+
+// coversDrivers reports whether there is an OpenF1 position for every driver
+func coversDrivers(official map[int]int, states map[int]DriverState) bool {
+	if len(official) == 0 {
+		return false
+	}
+	for driverNumber := range states {
+		if official[driverNumber] <= 0 {
+			return false
+		}
+	}
+	return true
+}
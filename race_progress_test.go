@@ -0,0 +1,143 @@
+package f1viz
+
+import (
+	"math"
+	"testing"
+	"time"
+)
+
+func TestLapCounterUpdate(t *testing.T) {
+	const loopLen = 144
+	start := time.Date(2023, 9, 17, 12, 0, 0, 0, time.UTC)
+	at := func(seconds int) time.Time {
+		return start.Add(time.Duration(seconds) * time.Second)
+	}
+
+	type sample struct {
+		index int
+		date  time.Time
+	}
+	tests := []struct {
+		name    string
+		laps    []Lap
+		samples []sample
+		want    float64
+	}{
+		{
+			name:    "first lap from the line",
+			samples: []sample{{0, at(0)}, {10, at(1)}, {36, at(2)}},
+			want:    36.0 / loopLen,
+		},
+		{
+			name:    "grid behind the line",
+			samples: []sample{{130, at(0)}},
+			want:    -1 + 130.0/loopLen,
+		},
+		{
+			name:    "grid behind the line then crossing it",
+			samples: []sample{{130, at(0)}, {140, at(1)}, {5, at(2)}},
+			want:    5.0 / loopLen,
+		},
+		{
+			name:    "wrap past the line counts a lap",
+			samples: []sample{{0, at(0)}, {72, at(1)}, {140, at(2)}, {3, at(3)}},
+			want:    1 + 3.0/loopLen,
+		},
+		{
+			name:    "small slip backwards keeps the lap",
+			samples: []sample{{0, at(0)}, {72, at(1)}, {70, at(2)}},
+			want:    70.0 / loopLen,
+		},
+		{
+			name:    "slipping back over the line uncounts a lap",
+			samples: []sample{{2, at(0)}, {142, at(1)}},
+			want:    -1 + 142.0/loopLen,
+		},
+		{
+			name: "official lap count wins away from the line",
+			laps: []Lap{
+				{LapNumber: 1, DateStart: at(0).Format(time.RFC3339)},
+				{LapNumber: 2, DateStart: at(90).Format(time.RFC3339)},
+				{LapNumber: 3, DateStart: at(180).Format(time.RFC3339)},
+			},
+			// Joins mid-race without having seen a crossing
+			samples: []sample{{72, at(200)}},
+			want:    2 + 72.0/loopLen,
+		},
+		{
+			name: "own crossing counts near the line before the official lap starts",
+			laps: []Lap{
+				{LapNumber: 2, DateStart: at(90).Format(time.RFC3339)},
+			},
+			samples: []sample{{72, at(30)}, {140, at(88)}, {2, at(89)}},
+			want:    1 + 2.0/loopLen,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c := newLapCounter(tt.laps)
+			var got float64
+			for _, s := range tt.samples {
+				got = c.update(s.index, loopLen, s.date)
+			}
+			if math.Abs(got-tt.want) > 1e-9 {
+				t.Errorf("race distance = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestAssignRunningOrder(t *testing.T) {
+	states := func() map[int]DriverState {
+		return map[int]DriverState{
+			1:  {DriverNumber: 1, RaceDistance: 3.2},
+			44: {DriverNumber: 44, RaceDistance: 3.5},
+			11: {DriverNumber: 11, RaceDistance: 3.2},
+			16: {DriverNumber: 16, TrackIndex: unknownTrackIndex},
+		}
+	}
+
+	tests := []struct {
+		name       string
+		official   map[int]int
+		wantPos    map[int]int
+		wantSource string
+	}{
+		{
+			name:       "OpenF1 positions",
+			official:   map[int]int{1: 4, 44: 7, 11: 5, 16: 9, 55: 1},
+			wantPos:    map[int]int{1: 4, 44: 7, 11: 5, 16: 9},
+			wantSource: positionSourceOpenF1,
+		},
+		{
+			name:       "no position data",
+			wantPos:    map[int]int{44: 1, 1: 2, 11: 3, 16: 0}, // Ties go to the lower number
+			wantSource: positionSourceRaceDistance,
+		},
+		{
+			name:       "position data missing a driver",
+			official:   map[int]int{1: 4, 44: 7},
+			wantPos:    map[int]int{44: 1, 1: 2, 11: 3, 16: 0}, // Ties go to the lower number
+			wantSource: positionSourceRaceDistance,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := states()
+			assignRunningOrder(got, tt.official)
+			for driverNumber, want := range tt.wantPos {
+				if got[driverNumber].Position != want {
+					t.Errorf("driver %d position = %d, want %d", driverNumber, got[driverNumber].Position, want)
+				}
+			}
+			if got[1].PositionSource != tt.wantSource {
+				t.Errorf("position source = %q, want %q", got[1].PositionSource, tt.wantSource)
+			}
+			if gap := got[1].GapToLeaderLaps; math.Abs(gap-0.3) > 1e-9 {
+				t.Errorf("gap to leader = %v laps, want 0.3", gap)
+			}
+		})
+	}
+}
@@ -0,0 +1,112 @@
+package f1viz
+
+import (
+	"encoding/json"
+	"fmt"
+	"sort"
+	"time"
+)
+
+// DriverState is the latest known state of a driver during playback
+type DriverState struct {
+	DriverNumber int      `json:"driver_number"`
+	Location     Location `json:"location"`
+	TrackIndex   int      `json:"track_index"` // Position along the reference track, -1 if unknown
+	InPitLane    bool     `json:"in_pit_lane"`
+	PitProgress  float64  `json:"pit_progress"`  // 0 at the pit entry to 1 at the pit exit, while in the pit lane
+	Lap          int      `json:"lap"`           // Current lap number, 0 before the start
+	RaceDistance float64  `json:"race_distance"` // Completed laps plus the fraction of the current lap
+	Position     int      `json:"position"`      // Race position, 0 if unknown; see PositionSource
+	// "openf1" when Position is the race position reported by OpenF1, or "race_distance" when OpenF1
+	// positions are unavailable and it is only the rank among the followed drivers
+	PositionSource  string  `json:"position_source"`
+	GapToLeaderLaps float64 `json:"gap_to_leader_laps"` // Race distance behind the furthest-ahead followed driver, in laps
+	Speed           int     `json:"speed"`              // km/h, 0 if unknown
+	Compound        string  `json:"compound"`           // Tyre compound, empty if unknown
+	TyreAge         int     `json:"tyre_age"`           // Laps on the current tyres
+	Heading         float64 `json:"heading"`            // Direction of travel in degrees, counter-clockwise from the X axis
+}
+
+// RaceState is a snapshot of every driver being played back
+type RaceState struct {
+	Running      bool          `json:"running"` // Whether a replay is in progress
+	PlaybackTime string        `json:"playback_time"`
+	TrackPoints  int           `json:"track_points"` // Points around the reference track; track indices run from 0 to track_points-1
+	Focus        int           `json:"focus"`        // Driver highlighted by focus mode, 0 for none
+	Drivers      []DriverState `json:"drivers"`      // Ordered by driver number
+}
+
+// updateDriverStates records the latest state of each rendered driver and derives the running order
+// at playbackTime
+func (s *vizF1viz) updateDriverStates(states map[int]DriverState, playbackTime time.Time) {
+	s.stateMu.Lock()
+	defer s.stateMu.Unlock()
+
+	var official map[int]int
+	if s.positions != nil {
+		official = s.positions.positionsAt(playbackTime)
+	}
+	assignRunningOrder(states, official)
+	s.driverStates = states
+}
+
+// raceState returns a snapshot of the current playback state
+func (s *vizF1viz) raceState() RaceState {
+	state := RaceState{Running: s.started.Load()}
+	if playbackTime := s.currentPlaybackTime(); !playbackTime.IsZero() {
+		state.PlaybackTime = playbackTime.Format(time.RFC3339Nano)
+	}
+	if track := s.currentReferenceTrack(); track != nil {
+		state.TrackPoints = len(track.Points)
+	}
+
+	s.stateMu.RLock()
+	state.Focus = s.focusDriver
+	s.stateMu.RUnlock()
+
+	state.Drivers = s.driverStateList()
+	sort.Slice(state.Drivers, func(i, j int) bool {
+		return state.Drivers[i].DriverNumber < state.Drivers[j].DriverNumber
+	})
+	return state
+}
+
+// driverStateList returns a copy of every driver's latest state, in no particular order
+func (s *vizF1viz) driverStateList() []DriverState {
+	s.stateMu.RLock()
+	defer s.stateMu.RUnlock()
+	drivers := make([]DriverState, 0, len(s.driverStates))
+	for _, driver := range s.driverStates {
+		drivers = append(drivers, driver)
+	}
+	return drivers
+}
+
+// runningOrder returns every driver's latest state ordered by race position, drivers without one last
+func (s *vizF1viz) runningOrder() []DriverState {
+	drivers := s.driverStateList()
+	sort.Slice(drivers, func(i, j int) bool {
+		pi, pj := drivers[i].Position, drivers[j].Position
+		if (pi == 0) != (pj == 0) {
+			return pj == 0
+		}
+		if pi != pj {
+			return pi < pj
+		}
+		return drivers[i].DriverNumber < drivers[j].DriverNumber
+	})
+	return drivers
+}
+
+// toCommandResponse converts a struct into a DoCommand response map via JSON
+func toCommandResponse(v interface{}) (map[string]interface{}, error) {
+	data, err := json.Marshal(v)
+	if err != nil {
+		return nil, fmt.Errorf("failed to encode response: %w", err)
+	}
+	var resp map[string]interface{}
+	if err := json.Unmarshal(data, &resp); err != nil {
+		return nil, fmt.Errorf("failed to encode response: %w", err)
+	}
+	return resp, nil
+}
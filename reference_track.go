@@ -0,0 +1,341 @@
+package f1viz
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"math"
+	"os"
+)
+
+const (
+	// defaultTrackResolution is the number of points in a reference track when none is configured
+	// (one per index 0-143, matching a common LED strip length)
+	defaultTrackResolution = 144
+	// minTrackResolution is the fewest points a reference track may have
+	minTrackResolution = 8
+	// maxSegmentRatio is how much longer than average a single track segment may be before the track is rejected
+	maxSegmentRatio = 5.0
+)
+
+type TrackPoint struct {
+	X int `json:"x"`
+	Y int `json:"y"`
+	Z int `json:"z"`
+}
+
+// ReferenceTrack contains N points representing the track layout (one per index 0 to N-1). The loop is
+// closed implicitly: the last point is followed by the first.
+type ReferenceTrack struct {
+	CircuitKey int          `json:"circuit_key,omitempty"` // OpenF1 circuit key the track was built for
+	Resolution int          `json:"resolution,omitempty"`  // Number of points; inferred from Points when omitted
+	StartPoint TrackPoint   `json:"start_point"`
+	Points     []TrackPoint `json:"points"` // Resolution points, index 0 to Resolution-1
+	// Track indices where each sector starts; defaults to three equal sectors
+	Sectors []int `json:"sectors,omitempty"`
+	// Track indices where each mini-sector starts, including every sector start; defaults to splitting every sector evenly
+	MiniSectors []int `json:"mini_sectors,omitempty"`
+	// Number of mini-sectors per sector when MiniSectors is not set (default 8)
+	MiniSectorsPerSector int `json:"mini_sectors_per_sector,omitempty"`
+	// Optional pit lane path
+	PitLane *PitLane `json:"pit_lane,omitempty"`
+	// How the track was generated, if it was generated by this module
+	Quality *TrackQuality `json:"quality,omitempty"`
+}
+
+// distance2D calculates the 2D Euclidean distance between two points (ignoring Z)
+func distance2D(x1, y1, x2, y2 int) float64 {
+	dx := float64(x2 - x1)
+	dy := float64(y2 - y1)
+	return math.Sqrt(dx*dx + dy*dy)
+}
+
+// closedPoints returns the track's points with the first point repeated at the end
+func (t *ReferenceTrack) closedPoints() []TrackPoint {
+	return closePath(t.Points)
+}
+
+// closePath returns a copy of a loop's points with the first point repeated at the end
+func closePath(points []TrackPoint) []TrackPoint {
+	closed := make([]TrackPoint, 0, len(points)+1)
+	closed = append(closed, points...)
+	return append(closed, points[0])
+}
+
+// loadReferenceTrack loads a reference track from a JSON file
+func loadReferenceTrack(filename string) (*ReferenceTrack, error) {
+	data, err := os.ReadFile(filename)
+	if err != nil {
+		return nil, err
+	}
+
+	var track ReferenceTrack
+	err = json.Unmarshal(data, &track)
+	if err != nil {
+		return nil, err
+	}
+
+	// Older tracks repeat the first point at the end to close the loop
+	if n := len(track.Points); n > 1 && track.Points[0] == track.Points[n-1] {
+		track.Points = track.Points[:n-1]
+		if track.Resolution == n {
+			track.Resolution = n - 1
+		}
+	}
+	if track.Resolution == 0 {
+		track.Resolution = len(track.Points)
+	}
+	if err := validateReferenceTrack(&track); err != nil {
+		return nil, err
+	}
+
+	return &track, nil
+}
+
+// saveReferenceTrack saves a reference track to a JSON file
+func saveReferenceTrack(track *ReferenceTrack, filename string) error {
+	data, err := json.MarshalIndent(track, "", "  ")
+	if err != nil {
+		return err
+	}
+
+	return os.WriteFile(filename, data, 0644)
+}
+
+// validateReferenceTrack checks that a track has as many points as its resolution and has no large gaps,
+// including between its last and first points
+func validateReferenceTrack(track *ReferenceTrack) error {
+	if len(track.Points) != track.Resolution {
+		return fmt.Errorf("reference track resolution is %d but it has %d points", track.Resolution, len(track.Points))
+	}
+	if track.Resolution < minTrackResolution {
+		return fmt.Errorf("reference track must have at least %d points, got %d", minTrackResolution, track.Resolution)
+	}
+
+	n := len(track.Points)
+	var totalDist, maxDist float64
+	for i := 1; i < n; i++ {
+		prev, cur := track.Points[i-1], track.Points[i]
+		dist := distance2D(prev.X, prev.Y, cur.X, cur.Y)
+		totalDist += dist
+		maxDist = math.Max(maxDist, dist)
+	}
+	first, last := track.Points[0], track.Points[n-1]
+	closure := distance2D(first.X, first.Y, last.X, last.Y)
+	totalDist += closure
+	avgDist := totalDist / float64(n)
+	if avgDist == 0 {
+		return fmt.Errorf("reference track has no length")
+	}
+	if maxDist > avgDist*maxSegmentRatio {
+		return fmt.Errorf("reference track has a gap of %.0f, more than %.0fx the average segment length %.0f", maxDist, maxSegmentRatio, avgDist)
+	}
+
+	if closure > avgDist*maxSegmentRatio {
+		return fmt.Errorf("reference track does not close: first and last points are %.0f apart", closure)
+	}
+
+	if err := validateSectors(track); err != nil {
+		return err
+	}
+	return validatePitLane(track)
+}
+
+// resamplePath returns n points evenly spaced by 2D distance along a path, including both of its ends
+func resamplePath(path []TrackPoint, n int) ([]TrackPoint, error) {
+	if len(path) < 2 {
+		return nil, fmt.Errorf("need at least 2 points to resample a path, got %d", len(path))
+	}
+
+	// Calculate cumulative distances along the path
+	cumulativeDistances := make([]float64, len(path))
+	for i := 1; i < len(path); i++ {
+		dist := distance2D(path[i-1].X, path[i-1].Y, path[i].X, path[i].Y)
+		cumulativeDistances[i] = cumulativeDistances[i-1] + dist
+	}
+	pathLength := cumulativeDistances[len(cumulativeDistances)-1]
+	if pathLength == 0 {
+		return nil, fmt.Errorf("path has no length")
+	}
+
+	points := make([]TrackPoint, n)
+	j := 0
+	for i := 0; i < n; i++ {
+		// Target distance for this index (0 to pathLength)
+		targetDist := (float64(i) / float64(n-1)) * pathLength
+
+		// Find the two points that bracket this distance and interpolate between them
+		for j < len(path)-2 && cumulativeDistances[j+1] < targetDist {
+			j++
+		}
+		dist1 := cumulativeDistances[j]
+		dist2 := cumulativeDistances[j+1]
+		ratio := 0.0
+		if dist2 > dist1 {
+			ratio = math.Min(1, (targetDist-dist1)/(dist2-dist1))
+		}
+
+		points[i] = TrackPoint{
+			X: int(math.Round(float64(path[j].X) + ratio*float64(path[j+1].X-path[j].X))),
+			Y: int(math.Round(float64(path[j].Y) + ratio*float64(path[j+1].Y-path[j].Y))),
+			Z: int(math.Round(float64(path[j].Z) + ratio*float64(path[j+1].Z-path[j].Z))),
+		}
+	}
+	return points, nil
+}
+
+// resampleLoopPath returns n points evenly spaced by 2D distance around a closed loop, starting at its
+// first point
+func resampleLoopPath(loop []TrackPoint, n int) ([]TrackPoint, error) {
+	points, err := resamplePath(closePath(loop), n+1)
+	if err != nil {
+		return nil, err
+	}
+	return points[:n], nil
+}
+
+// resampleReferenceTrack returns a copy of a track with a different number of points
+func resampleReferenceTrack(track *ReferenceTrack, resolution int) (*ReferenceTrack, error) {
+	points, err := resampleLoopPath(track.Points, resolution)
+	if err != nil {
+		return nil, err
+	}
+	return &ReferenceTrack{
+		CircuitKey:           track.CircuitKey,
+		Resolution:           resolution,
+		StartPoint:           track.StartPoint,
+		Points:               points,
+		Sectors:              scaleIndices(track.Sectors, len(track.Points), resolution),
+		MiniSectors:          scaleIndices(track.MiniSectors, len(track.Points), resolution),
+		MiniSectorsPerSector: track.MiniSectorsPerSector,
+		PitLane:              resamplePitLane(track, resolution),
+		Quality:              track.Quality,
+	}, nil
+}
+
+// resamplePitLane returns a copy of a track's pit lane with its entry and exit mapped to a new resolution
+func resamplePitLane(track *ReferenceTrack, resolution int) *PitLane {
+	if track.PitLane == nil {
+		return nil
+	}
+	from, to := len(track.Points), resolution
+	scale := func(i int) int {
+		return int(math.Round(float64(i)*float64(to)/float64(from))) % to
+	}
+	return &PitLane{
+		EntryIndex: scale(track.PitLane.EntryIndex),
+		ExitIndex:  scale(track.PitLane.ExitIndex),
+		Points:     track.PitLane.Points,
+	}
+}
+
+// generateReferenceTrackRequest holds the arguments of a generate_reference_track command
+type generateReferenceTrackRequest struct {
+	SessionKey    int    `json:"session_key"` // Defaults to the configured session
+	DriverNumber  int    `json:"driver_number"`
+	DriverNumbers []int  `json:"driver_numbers"`
+	LapNumber     int    `json:"lap_number"`
+	LapNumbers    []int  `json:"lap_numbers"` // Used for every driver; defaults to each driver's fastest clean laps
+	Laps          int    `json:"laps"`        // Number of clean laps per driver when no laps are listed
+	Smoothing     *int   `json:"smoothing"`   // Moving average half-width in points
+	PitLane       bool   `json:"pit_lane"`    // Also generate a pit lane from the drivers' pit stops
+	Path          string `json:"path"`        // Defaults to the circuit's file in the reference track library
+}
+
+// generateReferenceTrackCommand builds a reference track from laps of OpenF1 location data,
+// validates it, saves it to disk, makes it the active track and draws it
+func (s *vizF1viz) generateReferenceTrackCommand(ctx context.Context, cmdValue interface{}) (map[string]interface{}, error) {
+	var req generateReferenceTrackRequest
+	if err := decodeCommandArgs(cmdValue, &req); err != nil {
+		return nil, fmt.Errorf("generate_reference_track: %w", err)
+	}
+
+	opts := trackGenerationOptions{
+		driverNumbers: req.DriverNumbers,
+		lapNumbers:    req.LapNumbers,
+		laps:          req.Laps,
+		smoothing:     defaultSmoothing,
+	}
+	if req.DriverNumber > 0 {
+		opts.driverNumbers = append(opts.driverNumbers, req.DriverNumber)
+	}
+	if req.LapNumber > 0 {
+		opts.lapNumbers = append(opts.lapNumbers, req.LapNumber)
+	}
+	if req.Smoothing != nil {
+		opts.smoothing = *req.Smoothing
+	}
+	if len(opts.driverNumbers) == 0 {
+		return nil, fmt.Errorf("generate_reference_track requires driver_number or driver_numbers")
+	}
+
+	var session Session
+	var err error
+	if req.SessionKey == 0 {
+		session, err = s.fetchSession(ctx)
+	} else {
+		session, err = fetchSessionByKey(ctx, req.SessionKey)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch session: %w", err)
+	}
+
+	track, err := s.buildReferenceTrack(ctx, session, opts)
+	if err != nil {
+		return nil, err
+	}
+	if req.PitLane {
+		pit, err := s.buildPitLane(ctx, session, opts.driverNumbers, track)
+		if err != nil {
+			return nil, fmt.Errorf("failed to generate pit lane: %w", err)
+		}
+		track.PitLane = pit
+	}
+
+	if req.Path == "" {
+		req.Path, err = s.trackLibrary.save(track)
+	} else {
+		err = saveReferenceTrack(track, req.Path)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to save reference track: %w", err)
+	}
+	s.logger.Infof("Saved reference track to %s", req.Path)
+
+	s.setReferenceTrack(track)
+
+	drawn := true
+	if err := s.drawReferenceTrack(); err != nil {
+		s.logger.Warnf("Failed to draw generated reference track: %v", err)
+		drawn = false
+	}
+
+	return map[string]interface{}{
+		"status":      "success",
+		"path":        req.Path,
+		"circuit_key": track.CircuitKey,
+		"points":      len(track.Points),
+		"laps":        len(track.Quality.Laps),
+		"pit_lane":    track.PitLane != nil,
+		"quality": map[string]interface{}{
+			"closure_error":  track.Quality.ClosureError,
+			"max_deviation":  track.Quality.MaxDeviation,
+			"mean_deviation": track.Quality.MeanDeviation,
+		},
+		"drawn": drawn,
+	}, nil
+}
+
+// currentReferenceTrack returns the active reference track, or nil if none is loaded
+func (s *vizF1viz) currentReferenceTrack() *ReferenceTrack {
+	s.trackMu.RLock()
+	defer s.trackMu.RUnlock()
+	return s.referenceTrack
+}
+
+// setReferenceTrack replaces the active reference track
+func (s *vizF1viz) setReferenceTrack(track *ReferenceTrack) {
+	s.trackMu.Lock()
+	defer s.trackMu.Unlock()
+	s.referenceTrack = track
+}
@@ -0,0 +1,138 @@
+package f1viz
+
+import (
+	"path/filepath"
+	"testing"
+)
+
+func TestResamplePath(t *testing.T) {
+	tests := []struct {
+		name    string
+		path    []TrackPoint
+		n       int
+		want    []TrackPoint
+		wantErr bool
+	}{
+		{
+			name: "straight line",
+			path: []TrackPoint{{X: 0}, {X: 100}},
+			n:    5,
+			want: []TrackPoint{{X: 0}, {X: 25}, {X: 50}, {X: 75}, {X: 100}},
+		},
+		{
+			name: "uneven input spacing",
+			path: []TrackPoint{{X: 0}, {X: 10}, {X: 90, Z: 8}, {X: 100, Z: 10}},
+			n:    3,
+			want: []TrackPoint{{X: 0}, {X: 50, Z: 4}, {X: 100, Z: 10}},
+		},
+		{
+			name: "around a corner",
+			path: []TrackPoint{{X: 0, Y: 0}, {X: 100, Y: 0}, {X: 100, Y: 100}},
+			n:    5,
+			want: []TrackPoint{{X: 0}, {X: 50}, {X: 100}, {X: 100, Y: 50}, {X: 100, Y: 100}},
+		},
+		{
+			name:    "single point",
+			path:    []TrackPoint{{X: 1}},
+			n:       4,
+			wantErr: true,
+		},
+		{
+			name:    "no length",
+			path:    []TrackPoint{{X: 1}, {X: 1}},
+			n:       4,
+			wantErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := resamplePath(tt.path, tt.n)
+			if tt.wantErr {
+				if err == nil {
+					t.Fatalf("expected an error, got %v", got)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if len(got) != len(tt.want) {
+				t.Fatalf("got %d points, want %d", len(got), len(tt.want))
+			}
+			for i := range got {
+				if got[i] != tt.want[i] {
+					t.Errorf("point %d = %v, want %v", i, got[i], tt.want[i])
+				}
+			}
+		})
+	}
+}
+
+func TestResampleLoopPath(t *testing.T) {
+	square := []TrackPoint{{X: 0, Y: 0}, {X: 100, Y: 0}, {X: 100, Y: 100}, {X: 0, Y: 100}}
+	for _, n := range []int{4, 8, 60, 144} {
+		points, err := resampleLoopPath(square, n)
+		if err != nil {
+			t.Fatalf("n=%d: %v", n, err)
+		}
+		if len(points) != n {
+			t.Errorf("n=%d: got %d points", n, len(points))
+		}
+		if points[0] == points[len(points)-1] {
+			t.Errorf("n=%d: last point repeats the first", n)
+		}
+	}
+}
+
+func TestLoadReferenceTrackDropsClosingPoint(t *testing.T) {
+	loop, err := resampleLoopPath([]TrackPoint{{X: 0, Y: 0}, {X: 1000, Y: 0}, {X: 1000, Y: 1000}, {X: 0, Y: 1000}}, 60)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name  string
+		track *ReferenceTrack
+	}{
+		{name: "distinct points", track: &ReferenceTrack{Points: loop}},
+		{name: "closing point repeated", track: &ReferenceTrack{Points: closePath(loop)}},
+		{name: "closing point counted in resolution", track: &ReferenceTrack{Resolution: 61, Points: closePath(loop)}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			path := filepath.Join(t.TempDir(), "track.json")
+			if err := saveReferenceTrack(tt.track, path); err != nil {
+				t.Fatal(err)
+			}
+			track, err := loadReferenceTrack(path)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if len(track.Points) != 60 || track.Resolution != 60 {
+				t.Errorf("loaded %d points with resolution %d, want 60", len(track.Points), track.Resolution)
+			}
+		})
+	}
+}
+
+func TestBundledReferenceTracks(t *testing.T) {
+	files, err := filepath.Glob(filepath.Join(defaultReferenceTrackDir, "*.json"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, file := range files {
+		track, err := loadReferenceTrack(file)
+		if err != nil {
+			t.Errorf("%s: %v", file, err)
+			continue
+		}
+		if track.Points[0] == track.Points[len(track.Points)-1] {
+			t.Errorf("%s repeats its first point at the end", file)
+		}
+		if len(track.Points) != defaultTrackResolution {
+			t.Errorf("%s has %d points, want %d", file, len(track.Points), defaultTrackResolution)
+		}
+	}
+}
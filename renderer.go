@@ -0,0 +1,275 @@
+package f1viz
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"image/color"
+	"slices"
+	"sync"
+	"time"
+
+	"go.viam.com/rdk/components/board"
+	"go.viam.com/rdk/logging"
+	"go.viam.com/rdk/resource"
+)
+
+const (
+	rendererMotionTools = "motion_tools"
+	rendererLEDStrip    = "led_strip"
+	rendererDashboard   = "dashboard"
+)
+
+// Renderer draws the replay somewhere: the motion-tools visualizer, an LED strip, a terminal and so on.
+// Calls are made from one goroutine at a time.
+type Renderer interface {
+	// DrawTrack draws the reference track, replacing whatever track was drawn before
+	DrawTrack(ctx context.Context, scene TrackScene) error
+	// DrawFrame draws every driver at one step of the replay, replacing the previous frame
+	DrawFrame(ctx context.Context, frame Frame) error
+	// Clear removes the drivers drawn by DrawFrame, leaving the track
+	Clear(ctx context.Context) error
+	// Close releases the renderer's resources, such as servers, devices and open files. What was drawn may
+	// be left in place, like the last frame in the visualizer; call Clear first to remove the drivers.
+	Close(ctx context.Context) error
+}
+
+// TrackScene is the reference track as it should currently be drawn
+type TrackScene struct {
+	Track  *ReferenceTrack
+	Colors []color.NRGBA // Colour of each track point: its mini-sector's timing status or a gradient
+}
+
+// Frame is the state of every driver at one step of the replay
+type Frame struct {
+	Track        *ReferenceTrack // Track the drivers' track indices refer to, nil if none is loaded
+	PlaybackTime time.Time
+	Flags        FlagState
+	Drivers      []DriverState         // In running order
+	Trails       map[int][]Location    // Recent locations per driver, oldest first and ending with the current one
+	TrailColors  map[int][]color.NRGBA // Colour of each trail point
+	Ghosts       map[int][]Location    // Last complete lap of each driver with lap ghosts enabled
+	Colors       map[int]color.NRGBA   // Colour of each driver
+	Acronyms     map[int]string        // Three letter abbreviation of each driver, e.g. "VER", or their number
+	Focus        int                   // Driver highlighted by focus mode, 0 for none
+}
+
+// validateRenderers checks the configured renderer names
+func (cfg *Config) validateRenderers(path string) error {
+	for _, name := range cfg.Renderers {
+		switch name {
+		case rendererMotionTools, rendererDashboard:
+		case rendererLEDStrip:
+			if cfg.Board == "" {
+				return fmt.Errorf("%s: the %q renderer requires a board", path, rendererLEDStrip)
+			}
+		default:
+			return fmt.Errorf("%s: unknown renderer %q, must be %q, %q or %q",
+				path, name, rendererMotionTools, rendererLEDStrip, rendererDashboard)
+		}
+	}
+	return nil
+}
+
+// rendererNames returns the configured renderers, defaulting to motion-tools plus the LED strip when a
+// board is configured and the dashboard when it has an address
+func (cfg *Config) rendererNames() []string {
+	if len(cfg.Renderers) > 0 {
+		return cfg.Renderers
+	}
+	names := []string{rendererMotionTools}
+	if cfg.Board != "" {
+		names = append(names, rendererLEDStrip)
+	}
+	if cfg.DashboardAddress != "" {
+		names = append(names, rendererDashboard)
+	}
+	return names
+}
+
+// newRenderers builds every configured renderer
+func newRenderers(deps resource.Dependencies, cfg *Config, logger logging.Logger) ([]Renderer, error) {
+	var renderers []Renderer
+	for _, name := range cfg.rendererNames() {
+		r, err := newRenderer(name, deps, cfg, logger)
+		if err != nil {
+			// Release anything already started, such as the dashboard's listener
+			for _, started := range renderers {
+				if closeErr := started.Close(context.Background()); closeErr != nil {
+					logger.Warnf("Failed to close %T: %v", started, closeErr)
+				}
+			}
+			return nil, err
+		}
+		renderers = append(renderers, r)
+	}
+	return renderers, nil
+}
+
+// newRenderer builds a renderer by name
+func newRenderer(name string, deps resource.Dependencies, cfg *Config, logger logging.Logger) (Renderer, error) {
+	switch name {
+	case rendererMotionTools:
+		return newMotionToolsRenderer(cfg, logger), nil
+	case rendererLEDStrip:
+		b, err := board.FromDependencies(deps, cfg.Board)
+		if err != nil {
+			return nil, fmt.Errorf("failed to get board %q: %w", cfg.Board, err)
+		}
+		return newLEDStrip(b, cfg), nil
+	case rendererDashboard:
+		address := cfg.DashboardAddress
+		if address == "" {
+			address = defaultDashboardAddress
+		}
+		return newDashboardRenderer(address, logger)
+	default:
+		return nil, fmt.Errorf("unknown renderer %q", name)
+	}
+}
+
+// localServices holds the f1viz services running in this process by name. Other models in the module
+// get a gRPC client for their service dependency, so they look the service up here to attach renderers.
+var (
+	localServicesMu sync.Mutex
+	localServices   = make(map[string]*vizF1viz)
+)
+
+// registerLocalService makes a service available to other models in the process
+func registerLocalService(s *vizF1viz) {
+	localServicesMu.Lock()
+	defer localServicesMu.Unlock()
+	localServices[s.name.ShortName()] = s
+}
+
+// unregisterLocalService removes a service registered with registerLocalService, unless it has been replaced
+func unregisterLocalService(s *vizF1viz) {
+	localServicesMu.Lock()
+	defer localServicesMu.Unlock()
+	if localServices[s.name.ShortName()] == s {
+		delete(localServices, s.name.ShortName())
+	}
+}
+
+// localService returns the service with a name if it runs in this process
+func localService(name string) (*vizF1viz, bool) {
+	localServicesMu.Lock()
+	defer localServicesMu.Unlock()
+	s, ok := localServices[name]
+	return s, ok
+}
+
+// addRenderer starts drawing with another renderer, drawing the active reference track into it straight away
+func (s *vizF1viz) addRenderer(ctx context.Context, r Renderer) error {
+	s.drawMu.Lock()
+	defer s.drawMu.Unlock()
+	s.renderers = append(s.renderers, r)
+	if scene, ok := s.trackScene(); ok {
+		return r.DrawTrack(ctx, scene)
+	}
+	return nil
+}
+
+// removeRenderer stops drawing with a renderer added with addRenderer
+func (s *vizF1viz) removeRenderer(r Renderer) {
+	s.drawMu.Lock()
+	defer s.drawMu.Unlock()
+	s.renderers = slices.DeleteFunc(s.renderers, func(other Renderer) bool {
+		return other == r
+	})
+}
+
+// trackScene returns the active reference track coloured by mini-sector timing, and false if no track is loaded
+func (s *vizF1viz) trackScene() (TrackScene, bool) {
+	track := s.currentReferenceTrack()
+	if track == nil {
+		return TrackScene{}, false
+	}
+
+	timing := s.currentSectorTiming()
+	lastIdx := len(track.Points) - 1
+	scene := TrackScene{Track: track, Colors: make([]color.NRGBA, len(track.Points))}
+	for i := range track.Points {
+		scene.Colors[i] = indexColor(i, lastIdx, timing)
+	}
+	return scene, true
+}
+
+// drawReferenceTrack draws the active reference track with every renderer
+func (s *vizF1viz) drawReferenceTrack() error {
+	scene, ok := s.trackScene()
+	if !ok {
+		return fmt.Errorf("no reference track loaded")
+	}
+
+	s.drawMu.Lock()
+	defer s.drawMu.Unlock()
+	var errs []error
+	for _, r := range s.renderers {
+		if err := r.DrawTrack(s.cancelCtx, scene); err != nil {
+			errs = append(errs, err)
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// renderFrame draws the latest driver states with every renderer, logging rather than returning
+// failures so one broken renderer does not stop the others
+func (s *vizF1viz) renderFrame(ctx context.Context, trails, ghosts map[int][]Location) {
+	frame := Frame{
+		Track:        s.currentReferenceTrack(),
+		PlaybackTime: s.currentPlaybackTime(),
+		Flags:        s.currentFlags(),
+		Drivers:      s.runningOrder(),
+		Trails:       make(map[int][]Location, len(trails)),
+		TrailColors:  make(map[int][]color.NRGBA),
+		Ghosts:       make(map[int][]Location),
+		Colors:       make(map[int]color.NRGBA),
+		Acronyms:     make(map[int]string),
+	}
+	mode := s.currentTrailColor()
+	for _, driver := range frame.Drivers {
+		frame.Colors[driver.DriverNumber] = s.teamColor(driver.DriverNumber)
+		frame.Acronyms[driver.DriverNumber] = s.driverAcronym(driver.DriverNumber)
+		frame.Trails[driver.DriverNumber] = append([]Location(nil), trails[driver.DriverNumber]...)
+		settings := s.trailSettingsFor(driver.DriverNumber)
+		frame.TrailColors[driver.DriverNumber] = trailColors(mode, settings.Fade, frame.Trails[driver.DriverNumber], frame.Colors[driver.DriverNumber])
+		if ghost := ghosts[driver.DriverNumber]; settings.Ghost && len(ghost) > 1 {
+			frame.Ghosts[driver.DriverNumber] = ghost
+		}
+	}
+	if frame.Focus = s.updateFocus(frame.Drivers); positionOf(frame.Drivers, frame.Focus) >= 0 {
+		dimUnfocused(&frame)
+	}
+	s.logger.Debugf("Rendering frame with %d drivers", len(frame.Drivers))
+
+	s.drawMu.Lock()
+	defer s.drawMu.Unlock()
+	for _, r := range s.renderers {
+		if err := r.DrawFrame(ctx, frame); err != nil {
+			s.logger.Debugf("Failed to render frame with %T: %v", r, err)
+		}
+	}
+}
+
+// clearRenderers removes the drivers from every renderer
+func (s *vizF1viz) clearRenderers(ctx context.Context) {
+	s.drawMu.Lock()
+	defer s.drawMu.Unlock()
+	for _, r := range s.renderers {
+		if err := r.Clear(ctx); err != nil {
+			s.logger.Warnf("Failed to clear %T: %v", r, err)
+		}
+	}
+}
+
+// closeRenderers closes every renderer
+func (s *vizF1viz) closeRenderers(ctx context.Context) {
+	s.drawMu.Lock()
+	defer s.drawMu.Unlock()
+	for _, r := range s.renderers {
+		if err := r.Close(ctx); err != nil {
+			s.logger.Warnf("Failed to close %T: %v", r, err)
+		}
+	}
+}
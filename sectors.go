@@ -0,0 +1,417 @@
+package f1viz
+
+import (
+	"fmt"
+	"image/color"
+	"math"
+	"slices"
+	"sort"
+	"sync"
+	"time"
+)
+
+const (
+	// defaultSectorCount is the number of equal sectors used when a track does not define any
+	defaultSectorCount = 3
+	// defaultMiniSectorsPerSector is how many equal mini-sectors each sector is split into by default
+	defaultMiniSectorsPerSector = 8
+	// sectorRedrawInterval limits how often the track is redrawn with new mini-sector colours
+	sectorRedrawInterval = time.Second
+	// maxMiniSectorJump is the most mini-sector boundaries a driver may cross between two samples before
+	// the jump is treated as a discontinuity rather than timed
+	maxMiniSectorJump = 2
+)
+
+// miniSectorStatus is the colour of a mini-sector on the timing screen
+type miniSectorStatus int
+
+const (
+	miniSectorNone   miniSectorStatus = iota
+	miniSectorYellow                  // Slower than the driver's personal best
+	miniSectorGreen                   // Personal best
+	miniSectorPurple                  // Overall best
+)
+
+// statusColors maps mini-sector statuses to track colours
+var statusColors = map[miniSectorStatus]color.NRGBA{
+	miniSectorYellow: {R: 255, G: 215, B: 0, A: 255},
+	miniSectorGreen:  {R: 0, G: 200, B: 0, A: 255},
+	miniSectorPurple: {R: 160, G: 32, B: 240, A: 255},
+}
+
+func (st miniSectorStatus) String() string {
+	switch st {
+	case miniSectorYellow:
+		return "yellow"
+	case miniSectorGreen:
+		return "green"
+	case miniSectorPurple:
+		return "purple"
+	default:
+		return ""
+	}
+}
+
+// sectorLayout returns the track indices where each sector and mini-sector starts, using the track's
+// definitions when present and equal splits otherwise. Indices are below loopLen.
+func (t *ReferenceTrack) sectorLayout(loopLen int) (sectorStarts, miniStarts []int) {
+	sectorStarts = t.Sectors
+	if len(sectorStarts) == 0 {
+		for i := 0; i < defaultSectorCount; i++ {
+			sectorStarts = append(sectorStarts, i*loopLen/defaultSectorCount)
+		}
+	}
+
+	miniStarts = t.MiniSectors
+	if len(miniStarts) == 0 {
+		perSector := t.MiniSectorsPerSector
+		if perSector <= 0 {
+			perSector = defaultMiniSectorsPerSector
+		}
+		for i, start := range sectorStarts {
+			end := loopLen
+			if i+1 < len(sectorStarts) {
+				end = sectorStarts[i+1]
+			}
+			for j := 0; j < perSector; j++ {
+				miniStart := start + j*(end-start)/perSector
+				if len(miniStarts) == 0 || miniStart > miniStarts[len(miniStarts)-1] {
+					miniStarts = append(miniStarts, miniStart)
+				}
+			}
+		}
+	}
+	return sectorStarts, miniStarts
+}
+
+// validateSectors checks that sector and mini-sector boundaries are increasing indices on the track and
+// that every sector starts a mini-sector
+func validateSectors(track *ReferenceTrack) error {
+	for name, starts := range map[string][]int{"sectors": track.Sectors, "mini_sectors": track.MiniSectors} {
+		for i, start := range starts {
+			if start < 0 || start >= len(track.Points) {
+				return fmt.Errorf("%s: index %d is outside the track", name, start)
+			}
+			if i > 0 && start <= starts[i-1] {
+				return fmt.Errorf("%s must be strictly increasing", name)
+			}
+		}
+	}
+	if len(track.MiniSectors) > 0 {
+		sectorStarts, _ := track.sectorLayout(len(track.Points))
+		for _, start := range sectorStarts {
+			if !slices.Contains(track.MiniSectors, start) {
+				return fmt.Errorf("mini_sectors must include every sector start, missing %d", start)
+			}
+		}
+	}
+	return nil
+}
+
+// scaleIndices maps track indices from one resolution to another
+func scaleIndices(indices []int, from, to int) []int {
+	if indices == nil {
+		return nil
+	}
+	scaled := make([]int, 0, len(indices))
+	for _, i := range indices {
+		j := int(math.Round(float64(i) * float64(to) / float64(from)))
+		if j >= to {
+			j = to - 1
+		}
+		if len(scaled) == 0 || j > scaled[len(scaled)-1] {
+			scaled = append(scaled, j)
+		}
+	}
+	return scaled
+}
+
+// driverSectorState is one driver's timing state and results
+type driverSectorState struct {
+	started         bool
+	globalMini      int       // Laps * mini-sector count + current mini-sector
+	miniEnteredAt   time.Time // Zero when the current mini-sector was not entered across its boundary
+	sectorEnteredAt time.Time // Zero when the current sector was not entered across its boundary
+	lastDistance    float64
+	lastDate        time.Time
+
+	lastMini    []time.Duration
+	bestMini    []time.Duration
+	lastSectors []time.Duration
+	bestSectors []time.Duration
+}
+
+// sectorTiming computes sector and mini-sector times for every driver from their race distance
+type sectorTiming struct {
+	mu sync.Mutex
+
+	loopLen      int
+	sectorStarts []int
+	miniStarts   []int
+	sectorOfMini []int // Sector each mini-sector belongs to
+
+	drivers      map[int]*driverSectorState
+	bestMini     []time.Duration // Overall best per mini-sector
+	bestSectors  []time.Duration // Overall best per sector
+	miniStatus   []miniSectorStatus
+	statusDriver []int // Driver whose latest time set each mini-sector's status
+	changed      bool  // Whether statuses changed since the track was last drawn
+}
+
+// newSectorTiming creates timing for a reference track matched with loopLen points
+func newSectorTiming(track *ReferenceTrack, loopLen int) *sectorTiming {
+	sectorStarts, miniStarts := track.sectorLayout(loopLen)
+	st := &sectorTiming{
+		loopLen:      loopLen,
+		sectorStarts: sectorStarts,
+		miniStarts:   miniStarts,
+		sectorOfMini: make([]int, len(miniStarts)),
+		drivers:      make(map[int]*driverSectorState),
+		bestMini:     make([]time.Duration, len(miniStarts)),
+		bestSectors:  make([]time.Duration, len(sectorStarts)),
+		miniStatus:   make([]miniSectorStatus, len(miniStarts)),
+		statusDriver: make([]int, len(miniStarts)),
+	}
+	for m, start := range miniStarts {
+		st.sectorOfMini[m] = sort.SearchInts(sectorStarts, start+1) - 1
+		if st.sectorOfMini[m] < 0 {
+			// Mini-sectors before the first sector boundary belong to the last sector of the previous lap
+			st.sectorOfMini[m] = len(sectorStarts) - 1
+		}
+	}
+	return st
+}
+
+// miniAt returns the mini-sector containing a track index
+func (st *sectorTiming) miniAt(index int) int {
+	m := sort.SearchInts(st.miniStarts, index+1) - 1
+	if m < 0 {
+		m = len(st.miniStarts) - 1
+	}
+	return m
+}
+
+// globalMini returns the number of mini-sector boundaries crossed to reach a race distance
+func (st *sectorTiming) globalMini(raceDistance float64) int {
+	lap := math.Floor(raceDistance)
+	index := int((raceDistance - lap) * float64(st.loopLen))
+	m := sort.SearchInts(st.miniStarts, index+1) - 1
+	return int(lap)*len(st.miniStarts) + m
+}
+
+// boundaryDistance returns the race distance at which a global mini-sector starts
+func (st *sectorTiming) boundaryDistance(globalMini int) float64 {
+	n := len(st.miniStarts)
+	lap := int(math.Floor(float64(globalMini) / float64(n)))
+	m := globalMini - lap*n
+	return float64(lap) + float64(st.miniStarts[m])/float64(st.loopLen)
+}
+
+// record updates a driver's timing with a new race distance. Boundary crossing times are interpolated
+// between samples. Returns true if any mini-sector status changed.
+func (st *sectorTiming) record(driverNumber int, raceDistance float64, date time.Time) bool {
+	if date.IsZero() || len(st.miniStarts) == 0 {
+		return false
+	}
+
+	st.mu.Lock()
+	defer st.mu.Unlock()
+
+	ds, ok := st.drivers[driverNumber]
+	if !ok {
+		ds = &driverSectorState{
+			lastMini:    make([]time.Duration, len(st.miniStarts)),
+			bestMini:    make([]time.Duration, len(st.miniStarts)),
+			lastSectors: make([]time.Duration, len(st.sectorStarts)),
+			bestSectors: make([]time.Duration, len(st.sectorStarts)),
+		}
+		st.drivers[driverNumber] = ds
+	}
+
+	g := st.globalMini(raceDistance)
+	changed := false
+	switch {
+	case !ds.started:
+		ds.started = true
+		ds.globalMini = g
+	case g < ds.globalMini || g > ds.globalMini+maxMiniSectorJump:
+		// Went backwards or jumped ahead, e.g. when the lap count is corrected or the matcher glitches.
+		// Times for the current mini-sector and sector are no longer valid, and interpolating the
+		// skipped crossings would record impossibly fast times.
+		ds.globalMini = g
+		ds.miniEnteredAt = time.Time{}
+		ds.sectorEnteredAt = time.Time{}
+	case g > ds.globalMini:
+		n := len(st.miniStarts)
+		for next := ds.globalMini + 1; next <= g; next++ {
+			crossedAt := interpolateTime(ds.lastDistance, raceDistance, ds.lastDate, date, st.boundaryDistance(next))
+			completed := ((next-1)%n + n) % n
+			entered := (next%n + n) % n
+
+			if !ds.miniEnteredAt.IsZero() {
+				changed = st.completeMini(driverNumber, ds, completed, crossedAt.Sub(ds.miniEnteredAt)) || changed
+			}
+			ds.miniEnteredAt = crossedAt
+
+			if sector := st.sectorOfMini[entered]; st.miniStarts[entered] == st.sectorStarts[sector] {
+				if !ds.sectorEnteredAt.IsZero() {
+					st.completeSector(ds, st.sectorOfMini[completed], crossedAt.Sub(ds.sectorEnteredAt))
+				}
+				ds.sectorEnteredAt = crossedAt
+			}
+		}
+		ds.globalMini = g
+	}
+
+	ds.lastDistance = raceDistance
+	ds.lastDate = date
+	return changed
+}
+
+// completeMini records a mini-sector time and updates its status. Returns true if the status changed.
+func (st *sectorTiming) completeMini(driverNumber int, ds *driverSectorState, m int, d time.Duration) bool {
+	if d <= 0 {
+		return false
+	}
+	ds.lastMini[m] = d
+
+	status := miniSectorYellow
+	if ds.bestMini[m] == 0 || d < ds.bestMini[m] {
+		ds.bestMini[m] = d
+		status = miniSectorGreen
+	}
+	if st.bestMini[m] == 0 || d < st.bestMini[m] {
+		st.bestMini[m] = d
+		status = miniSectorPurple
+	}
+
+	changed := st.miniStatus[m] != status || st.statusDriver[m] != driverNumber
+	st.miniStatus[m] = status
+	st.statusDriver[m] = driverNumber
+	st.changed = st.changed || changed
+	return changed
+}
+
+// completeSector records a sector time
+func (st *sectorTiming) completeSector(ds *driverSectorState, sector int, d time.Duration) {
+	if d <= 0 {
+		return
+	}
+	ds.lastSectors[sector] = d
+	if ds.bestSectors[sector] == 0 || d < ds.bestSectors[sector] {
+		ds.bestSectors[sector] = d
+	}
+	if st.bestSectors[sector] == 0 || d < st.bestSectors[sector] {
+		st.bestSectors[sector] = d
+	}
+}
+
+// statusAt returns the colour status of the mini-sector containing a track index
+func (st *sectorTiming) statusAt(index int) miniSectorStatus {
+	st.mu.Lock()
+	defer st.mu.Unlock()
+	if index >= st.loopLen {
+		index = 0
+	}
+	return st.miniStatus[st.miniAt(index)]
+}
+
+// takeChanged reports whether statuses changed since the last call
+func (st *sectorTiming) takeChanged() bool {
+	st.mu.Lock()
+	defer st.mu.Unlock()
+	changed := st.changed
+	st.changed = false
+	return changed
+}
+
+// interpolateTime estimates when a distance between two samples was reached
+func interpolateTime(d1, d2 float64, t1, t2 time.Time, target float64) time.Time {
+	if t1.IsZero() || d2 <= d1 {
+		return t2
+	}
+	ratio := math.Max(0, math.Min(1, (target-d1)/(d2-d1)))
+	return t1.Add(time.Duration(ratio * float64(t2.Sub(t1))))
+}
+
+// DriverSectorTimes holds a driver's sector and mini-sector times in seconds; 0 means no time yet
+type DriverSectorTimes struct {
+	DriverNumber    int       `json:"driver_number"`
+	LastMiniSectors []float64 `json:"last_mini_sectors"`
+	BestMiniSectors []float64 `json:"best_mini_sectors"`
+	LastSectors     []float64 `json:"last_sectors"`
+	BestSectors     []float64 `json:"best_sectors"`
+}
+
+// SectorTimes is the response of the get_sector_times command
+type SectorTimes struct {
+	SectorStarts     []int               `json:"sector_starts"`
+	MiniSectorStarts []int               `json:"mini_sector_starts"`
+	BestMiniSectors  []float64           `json:"best_mini_sectors"`
+	BestSectors      []float64           `json:"best_sectors"`
+	MiniSectorStatus []string            `json:"mini_sector_status"`
+	Drivers          []DriverSectorTimes `json:"drivers"`
+}
+
+// seconds converts durations to seconds
+func seconds(durations []time.Duration) []float64 {
+	out := make([]float64, len(durations))
+	for i, d := range durations {
+		out[i] = d.Seconds()
+	}
+	return out
+}
+
+// snapshot returns every driver's times
+func (st *sectorTiming) snapshot() SectorTimes {
+	st.mu.Lock()
+	defer st.mu.Unlock()
+
+	times := SectorTimes{
+		SectorStarts:     st.sectorStarts,
+		MiniSectorStarts: st.miniStarts,
+		BestMiniSectors:  seconds(st.bestMini),
+		BestSectors:      seconds(st.bestSectors),
+		MiniSectorStatus: make([]string, len(st.miniStatus)),
+		Drivers:          make([]DriverSectorTimes, 0, len(st.drivers)),
+	}
+	for i, status := range st.miniStatus {
+		times.MiniSectorStatus[i] = status.String()
+	}
+	for driverNumber, ds := range st.drivers {
+		times.Drivers = append(times.Drivers, DriverSectorTimes{
+			DriverNumber:    driverNumber,
+			LastMiniSectors: seconds(ds.lastMini),
+			BestMiniSectors: seconds(ds.bestMini),
+			LastSectors:     seconds(ds.lastSectors),
+			BestSectors:     seconds(ds.bestSectors),
+		})
+	}
+	sort.Slice(times.Drivers, func(i, j int) bool {
+		return times.Drivers[i].DriverNumber < times.Drivers[j].DriverNumber
+	})
+	return times
+}
+
+// currentSectorTiming returns the timing for the active replay, or nil before one has started
+func (s *vizF1viz) currentSectorTiming() *sectorTiming {
+	s.stateMu.RLock()
+	defer s.stateMu.RUnlock()
+	return s.sectorTiming
+}
+
+// setSectorTiming replaces the timing for the active replay
+func (s *vizF1viz) setSectorTiming(timing *sectorTiming) {
+	s.stateMu.Lock()
+	defer s.stateMu.Unlock()
+	s.sectorTiming = timing
+}
+
+// getSectorTimes handles the get_sector_times command
+func (s *vizF1viz) getSectorTimes() (map[string]interface{}, error) {
+	timing := s.currentSectorTiming()
+	if timing == nil {
+		return nil, fmt.Errorf("no sector timing yet, start a replay with a reference track first")
+	}
+	return toCommandResponse(timing.snapshot())
+}
@@ -0,0 +1,109 @@
+package f1viz
+
+import (
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestSectorTimingRecord(t *testing.T) {
+	track := &ReferenceTrack{Points: make([]TrackPoint, 144)}
+	start := time.Date(2023, 9, 17, 12, 0, 0, 0, time.UTC)
+
+	type sample struct {
+		distance float64
+		seconds  float64
+	}
+	// steadyLap drives one lap at a constant 0.004 laps every 0.3s, starting from distance and seconds
+	steadyLap := func(distance, seconds float64) []sample {
+		var samples []sample
+		for i := 0; i <= 250; i++ {
+			samples = append(samples, sample{distance + float64(i)*0.004, seconds + float64(i)*0.3})
+		}
+		return samples
+	}
+
+	tests := []struct {
+		name     string
+		samples  []sample
+		wantBest time.Duration // Overall best of the second mini-sector, 0 for none
+	}{
+		{
+			name:     "steady lap",
+			samples:  steadyLap(0, 0),
+			wantBest: 3125 * time.Millisecond,
+		},
+		{
+			name:     "first mini-sector is not timed when entered mid-way",
+			samples:  []sample{{0.03, 0}, {0.04, 1}, {0.06, 2}},
+			wantBest: 0,
+		},
+		{
+			name:     "jump ahead is not timed",
+			samples:  append(steadyLap(0, 0), append([]sample{{2.001, 75.3}}, steadyLap(2.005, 75.6)[:10]...)...),
+			wantBest: 3125 * time.Millisecond,
+		},
+		{
+			// Mini-sector 2 is entered at 0.0417 laps after about 8s, but the car slips back into mini-sector 1,
+			// so it is timed from when it is entered again at 21.9s until 28.9s
+			name:     "going backwards restarts timing",
+			samples:  []sample{{0, 0}, {0.05, 10}, {0.03, 20}, {0.09, 30}},
+			wantBest: 6944 * time.Millisecond,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			st := newSectorTiming(track, len(track.Points))
+			for _, s := range tt.samples {
+				st.record(1, s.distance, start.Add(time.Duration(s.seconds*float64(time.Second))))
+			}
+			if got := st.bestMini[1]; absDuration(got-tt.wantBest) > time.Millisecond {
+				t.Errorf("best mini-sector 2 = %v, want %v", got, tt.wantBest)
+			}
+			for m, best := range st.bestMini {
+				if best != 0 && best < time.Second {
+					t.Errorf("mini-sector %d has an impossible best of %v", m+1, best)
+				}
+			}
+		})
+	}
+}
+
+func absDuration(d time.Duration) time.Duration {
+	if d < 0 {
+		return -d
+	}
+	return d
+}
+
+func TestValidateSectors(t *testing.T) {
+	tests := []struct {
+		name    string
+		sectors []int
+		minis   []int
+		wantErr string
+	}{
+		{name: "defaults"},
+		{name: "custom", sectors: []int{0, 50, 100}, minis: []int{0, 25, 50, 75, 100, 120}},
+		{name: "mini-sectors with default sectors", minis: []int{0, 24, 48, 72, 96, 120}},
+		{name: "outside the track", sectors: []int{0, 50, 144}, wantErr: "outside the track"},
+		{name: "not increasing", sectors: []int{0, 100, 50}, wantErr: "strictly increasing"},
+		{name: "mini-sectors not increasing", minis: []int{0, 48, 48, 96}, wantErr: "strictly increasing"},
+		{name: "sector start missing from mini-sectors", sectors: []int{0, 50, 100}, minis: []int{0, 25, 75, 100}, wantErr: "missing 50"},
+		{name: "default sector start missing from mini-sectors", minis: []int{0, 40, 96}, wantErr: "missing 48"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			track := &ReferenceTrack{Points: make([]TrackPoint, 144), Sectors: tt.sectors, MiniSectors: tt.minis}
+			err := validateSectors(track)
+			switch {
+			case tt.wantErr == "" && err != nil:
+				t.Errorf("unexpected error: %v", err)
+			case tt.wantErr != "" && (err == nil || !strings.Contains(err.Error(), tt.wantErr)):
+				t.Errorf("error = %v, want one containing %q", err, tt.wantErr)
+			}
+		})
+	}
+}
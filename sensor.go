@@ -0,0 +1,106 @@
+package f1viz
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+
+	"go.viam.com/rdk/components/sensor"
+	"go.viam.com/rdk/logging"
+	"go.viam.com/rdk/resource"
+	generic "go.viam.com/rdk/services/generic"
+)
+
+// F1vizSensor reports the race state of an f1viz service as sensor readings
+var F1vizSensor = resource.NewModel("vijayvuyyuru", "viz", "f1viz-sensor")
+
+func init() {
+	resource.RegisterComponent(sensor.API, F1vizSensor,
+		resource.Registration[sensor.Sensor, *SensorConfig]{
+			Constructor: newVizSensor,
+		},
+	)
+}
+
+// SensorConfig configures an f1viz-sensor
+type SensorConfig struct {
+	// Name of the f1viz service whose replay is reported
+	Service string `json:"service"`
+}
+
+// Validate requires the f1viz service, which is the sensor's only dependency
+func (cfg *SensorConfig) Validate(path string) ([]string, []string, error) {
+	if cfg.Service == "" {
+		return nil, nil, resource.NewConfigValidationFieldRequiredError(path, "service")
+	}
+	return []string{cfg.Service}, nil, nil
+}
+
+// vizSensor reads the race state from an f1viz service with its get_state command
+type vizSensor struct {
+	resource.AlwaysRebuild
+	resource.TriviallyCloseable
+
+	name    resource.Name
+	service resource.Resource
+}
+
+func newVizSensor(ctx context.Context, deps resource.Dependencies, rawConf resource.Config, logger logging.Logger) (sensor.Sensor, error) {
+	conf, err := resource.NativeConfig[*SensorConfig](rawConf)
+	if err != nil {
+		return nil, err
+	}
+	service, err := generic.FromProvider(deps, conf.Service)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get f1viz service %q: %w", conf.Service, err)
+	}
+	return &vizSensor{name: rawConf.ResourceName(), service: service}, nil
+}
+
+func (s *vizSensor) Name() resource.Name {
+	return s.name
+}
+
+// Readings returns the playback time and, keyed by driver number, each tracked driver's position,
+// track index, lap, speed, gap to the leader in laps and tyres. position is the OpenF1 race position when
+// position_source is "openf1"; when it is "race_distance" it is only the rank among the followed drivers.
+func (s *vizSensor) Readings(ctx context.Context, extra map[string]interface{}) (map[string]interface{}, error) {
+	resp, err := s.service.DoCommand(ctx, map[string]interface{}{"get_state": true})
+	if err != nil {
+		return nil, fmt.Errorf("failed to get race state: %w", err)
+	}
+
+	readings := map[string]interface{}{
+		"running":       resp["running"],
+		"playback_time": resp["playback_time"],
+	}
+	drivers, _ := resp["drivers"].([]interface{})
+	for _, d := range drivers {
+		driver, ok := d.(map[string]interface{})
+		if !ok {
+			continue
+		}
+		number, err := toInt(driver["driver_number"])
+		if err != nil {
+			continue
+		}
+		readings[strconv.Itoa(number)] = map[string]interface{}{
+			"position":           driver["position"],
+			"position_source":    driver["position_source"],
+			"track_index":        driver["track_index"],
+			"lap":                driver["lap"],
+			"race_distance":      driver["race_distance"],
+			"speed":              driver["speed"],
+			"gap_to_leader_laps": driver["gap_to_leader_laps"],
+			"in_pit_lane":        driver["in_pit_lane"],
+			"compound":           driver["compound"],
+			"tyre_age":           driver["tyre_age"],
+		}
+	}
+	return readings, nil
+}
+
+// DoCommand forwards commands, such as start and stop, to the f1viz service
+func (s *vizSensor) DoCommand(ctx context.Context, cmd map[string]interface{}) (map[string]interface{}, error) {
+	return s.service.DoCommand(ctx, cmd)
+}
@@ -0,0 +1,102 @@
+package f1viz
+
+import (
+	"context"
+	"fmt"
+	"net/url"
+	"sort"
+	"time"
+)
+
+// CarData is a telemetry sample from the OpenF1 car_data endpoint
+type CarData struct {
+	Date         string `json:"date"`
+	DriverNumber int    `json:"driver_number"`
+	Speed        int    `json:"speed"`    // km/h
+	Throttle     int    `json:"throttle"` // Percent
+	Brake        int    `json:"brake"`    // 0 or 100
+	NGear        int    `json:"n_gear"`   // 0 is neutral
+	RPM          int    `json:"rpm"`
+	DRS          int    `json:"drs"`
+}
+
+// Stint is a run on one set of tyres from the OpenF1 stints endpoint
+type Stint struct {
+	DriverNumber   int    `json:"driver_number"`
+	StintNumber    int    `json:"stint_number"`
+	LapStart       int    `json:"lap_start"`
+	LapEnd         int    `json:"lap_end"`
+	Compound       string `json:"compound"` // e.g. SOFT, MEDIUM, HARD, INTERMEDIATE, WET
+	TyreAgeAtStart int    `json:"tyre_age_at_start"`
+}
+
+// fetchCarData fetches a driver's telemetry between two times
+func fetchCarData(ctx context.Context, sessionKey, driverNumber int, startTime, endTime time.Time) ([]CarData, error) {
+	query := fmt.Sprintf("session_key=%d&driver_number=%d&date>=%s&date<%s",
+		sessionKey, driverNumber,
+		url.QueryEscape(startTime.UTC().Format("2006-01-02T15:04:05.000")),
+		url.QueryEscape(endTime.UTC().Format("2006-01-02T15:04:05.000")))
+	var samples []CarData
+	if err := fetchOpenF1(ctx, "car_data", query, &samples); err != nil {
+		return nil, err
+	}
+	return samples, nil
+}
+
+// fetchStints fetches every stint for a driver in a session
+func fetchStints(ctx context.Context, sessionKey, driverNumber int) ([]Stint, error) {
+	var stints []Stint
+	query := fmt.Sprintf("session_key=%d&driver_number=%d", sessionKey, driverNumber)
+	if err := fetchOpenF1(ctx, "stints", query, &stints); err != nil {
+		return nil, err
+	}
+	return stints, nil
+}
+
+// attachCarData gives each location the latest telemetry sample at or before it, or the first sample
+// when the location comes before all of them
+func attachCarData(locations []Location, samples []CarData) {
+	if len(samples) == 0 {
+		return
+	}
+	times := make([]time.Time, len(samples))
+	for i, sample := range samples {
+		times[i], _ = time.Parse(time.RFC3339, sample.Date)
+	}
+	sort.Sort(carDataByTime{samples, times})
+
+	for i := range locations {
+		date, err := time.Parse(time.RFC3339, locations[i].Date)
+		if err != nil {
+			continue
+		}
+		j := sort.Search(len(times), func(j int) bool {
+			return times[j].After(date)
+		})
+		sample := samples[max(0, j-1)]
+		locations[i].CarData = &sample
+	}
+}
+
+// carDataByTime sorts telemetry samples by their parsed dates
+type carDataByTime struct {
+	samples []CarData
+	times   []time.Time
+}
+
+func (c carDataByTime) Len() int           { return len(c.samples) }
+func (c carDataByTime) Less(i, j int) bool { return c.times[i].Before(c.times[j]) }
+func (c carDataByTime) Swap(i, j int) {
+	c.samples[i], c.samples[j] = c.samples[j], c.samples[i]
+	c.times[i], c.times[j] = c.times[j], c.times[i]
+}
+
+// tyreOnLap returns the compound and age in laps of a driver's tyres on a lap, or an empty compound if unknown
+func tyreOnLap(stints []Stint, lap int) (string, int) {
+	for _, stint := range stints {
+		if lap >= stint.LapStart && (stint.LapEnd == 0 || lap <= stint.LapEnd) {
+			return stint.Compound, stint.TyreAgeAtStart + lap - stint.LapStart
+		}
+	}
+	return "", 0
+}
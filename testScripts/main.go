@@ -35,6 +35,10 @@ type Location struct {
 	Z            int    `json:"z"`
 }
 
+// referenceTrackFile is the module's bundled reference track for circuit 9, which is generated with the
+// module's generate_reference_track command. Run the script from the repository root.
+const referenceTrackFile = "reference_tracks/9.json"
+
 // TrackPoint represents a point on the reference track
 type TrackPoint struct {
 	X int `json:"x"`
@@ -55,108 +59,11 @@ func distance2D(x1, y1, x2, y2 int) float64 {
 	return math.Sqrt(dx*dx + dy*dy)
 }
 
-// generateReferenceTrack creates a reference track with 144 points from location data
-// It extracts one complete lap and divides it into 144 evenly spaced segments
-func generateReferenceTrack(locations []Location, startPoint TrackPoint) (*ReferenceTrack, error) {
-	if len(locations) < 144 {
-		return nil, fmt.Errorf("need at least 144 locations to generate reference track")
-	}
-
-	// Find the first complete lap by detecting when we return close to the start point
-	startX := startPoint.X
-	startY := startPoint.Y
-	threshold := 1000.0 // Distance threshold to consider "close" to start
-
-	var lapEndIdx int = len(locations) - 1
-	for i := 100; i < len(locations); i++ {
-		dist := distance2D(locations[i].X, locations[i].Y, startX, startY)
-		if dist < threshold {
-			lapEndIdx = i
-			break
-		}
-	}
-
-	// Use the first complete lap
-	lapLocations := locations[0 : lapEndIdx+1]
-	if len(lapLocations) < 144 {
-		// If lap is shorter than 144 points, use all available points
-		lapLocations = locations
-	}
-
-	// Calculate cumulative distances along the lap
-	cumulativeDistances := make([]float64, len(lapLocations))
-	cumulativeDistances[0] = 0.0
-	var totalDist float64
-	for i := 1; i < len(lapLocations); i++ {
-		dist := distance2D(lapLocations[i-1].X, lapLocations[i-1].Y, lapLocations[i].X, lapLocations[i].Y)
-		totalDist += dist
-		cumulativeDistances[i] = totalDist
-	}
-
-	// Add distance from last point back to start point to close the loop
-	distToStart := distance2D(lapLocations[len(lapLocations)-1].X, lapLocations[len(lapLocations)-1].Y, startX, startY)
-	trackLength := totalDist + distToStart
-
-	// Create 144 evenly spaced points along the track
-	referencePoints := make([]TrackPoint, 144)
-
-	for i := 0; i < 144; i++ {
-		// Target distance for this index (0 to trackLength)
-		// For i=143, we want to be at trackLength (back to start)
-		targetDist := (float64(i) / 143.0) * trackLength
-
-		// Find the two points that bracket this distance
-		var point TrackPoint
-		if i == 0 || i == 143 {
-			// First and last points are always the start point to close the loop
-			point = TrackPoint{X: startX, Y: startY, Z: startPoint.Z}
-		} else {
-			// Interpolate between two points
-			found := false
-			for j := 0; j < len(cumulativeDistances)-1; j++ {
-				if cumulativeDistances[j] <= targetDist && cumulativeDistances[j+1] >= targetDist {
-					// Interpolate between points j and j+1
-					dist1 := cumulativeDistances[j]
-					dist2 := cumulativeDistances[j+1]
-					ratio := (targetDist - dist1) / (dist2 - dist1)
-
-					point = TrackPoint{
-						X: int(float64(lapLocations[j].X) + ratio*float64(lapLocations[j+1].X-lapLocations[j].X)),
-						Y: int(float64(lapLocations[j].Y) + ratio*float64(lapLocations[j+1].Y-lapLocations[j].Y)),
-						Z: int(float64(lapLocations[j].Z) + ratio*float64(lapLocations[j+1].Z-lapLocations[j].Z)),
-					}
-					found = true
-					break
-				}
-			}
-			// If target distance is beyond the last point, interpolate from last point to start
-			if !found && targetDist > cumulativeDistances[len(cumulativeDistances)-1] {
-				// Interpolate from last point to start point
-				dist1 := cumulativeDistances[len(cumulativeDistances)-1]
-				ratio := (targetDist - dist1) / distToStart
-
-				lastPoint := lapLocations[len(lapLocations)-1]
-				point = TrackPoint{
-					X: int(float64(lastPoint.X) + ratio*float64(startX-lastPoint.X)),
-					Y: int(float64(lastPoint.Y) + ratio*float64(startY-lastPoint.Y)),
-					Z: int(float64(lastPoint.Z) + ratio*float64(startPoint.Z-lastPoint.Z)),
-				}
-			}
-		}
-		referencePoints[i] = point
-	}
-
-	return &ReferenceTrack{
-		StartPoint: startPoint,
-		Points:     referencePoints,
-	}, nil
-}
-
 // mapLocationToIndex maps a single location to an index 0-143 using the reference track
 // Returns the index of the closest point on the reference track
 //
 // Production usage:
-//  1. Load reference track once at startup: track, err := loadReferenceTrack("reference_track.json")
+//  1. Load reference track once at startup: track, err := loadReferenceTrack(referenceTrackFile)
 //  2. For each location, call: index := mapLocationToIndex(location, track)
 //  3. Index will be 0-143 representing position along the track
 func mapLocationToIndex(location Location, track *ReferenceTrack) int {
@@ -199,16 +106,6 @@ func loadReferenceTrack(filename string) (*ReferenceTrack, error) {
 	return &track, nil
 }
 
-// saveReferenceTrack saves a reference track to a JSON file
-func saveReferenceTrack(track *ReferenceTrack, filename string) error {
-	data, err := json.MarshalIndent(track, "", "  ")
-	if err != nil {
-		return err
-	}
-
-	return os.WriteFile(filename, data, 0644)
-}
-
 // mapLocationsToIndices maps locations to indices 0-143 based on cumulative distance along path
 // Treats the first point as the start of a new lap
 func mapLocationsToIndices(locations []Location) []int {
@@ -428,33 +325,6 @@ func main() {
 		os.Exit(1)
 	}
 
-	// Generate and save reference track for production use
-	if len(locations) > 0 {
-		startPoint := TrackPoint{
-			X: locations[0].X,
-			Y: locations[0].Y,
-			Z: locations[0].Z,
-		}
-
-		// Generate reference track from location data
-		referenceTrack, err := generateReferenceTrack(locations, startPoint)
-		if err != nil {
-			fmt.Printf("Error generating reference track: %v\n", err)
-		} else {
-			// Save reference track to JSON file
-			err = saveReferenceTrack(referenceTrack, "reference_track.json")
-			if err != nil {
-				fmt.Printf("Error saving reference track: %v\n", err)
-			} else {
-				fmt.Printf("Reference track written to reference_track.json\n")
-				fmt.Printf("Reference track contains %d points (indices 0-143)\n", len(referenceTrack.Points))
-			}
-
-			// Draw reference track as pointcloud
-			drawReferenceTrack(referenceTrack)
-		}
-	}
-
 	// Map locations to indices 0-143
 	indices := mapLocationsToIndices(locations)
 	fmt.Printf("Mapped %d locations to indices 0-143\n", len(indices))
@@ -506,10 +376,10 @@ func main() {
 
 	// Production usage example: Load reference track and map arbitrary locations
 	fmt.Println("\n=== Production Usage Example ===")
-	referenceTrack, err := loadReferenceTrack("reference_track.json")
+	referenceTrack, err := loadReferenceTrack(referenceTrackFile)
 	if err != nil {
 		fmt.Printf("Could not load reference track: %v\n", err)
-		fmt.Println("(This is expected if reference_track.json doesn't exist yet)")
+		fmt.Println("(Generate one with the module's generate_reference_track command)")
 	} else {
 		fmt.Println("Reference track loaded successfully")
 
@@ -0,0 +1,166 @@
+package f1viz
+
+import (
+	"fmt"
+	"image/color"
+	"math"
+
+	"github.com/golang/geo/r3"
+	vizClient "github.com/viam-labs/motion-tools/client/client"
+	"go.viam.com/rdk/pointcloud"
+	"go.viam.com/rdk/spatialmath"
+)
+
+const (
+	trackStyleLine   = "line"
+	trackStyleRibbon = "ribbon"
+	trackStylePoints = "points"
+
+	// defaultTrackWidth is the ribbon width in OpenF1 units, roughly a 15m wide circuit
+	defaultTrackWidth = 150.0
+	// markerWidthRatio is how much wider than the track the start/finish line and sector markers are drawn
+	markerWidthRatio = 1.5
+)
+
+var (
+	startFinishColor  = [3]uint8{255, 255, 255}
+	sectorMarkerColor = [3]uint8{255, 0, 0}
+	pitLaneColor      = [3]uint8{128, 128, 128}
+)
+
+// trackStyle returns the configured track style, defaulting to a line
+func (cfg *Config) trackStyle() string {
+	if cfg == nil || cfg.TrackStyle == "" {
+		return trackStyleLine
+	}
+	return cfg.TrackStyle
+}
+
+// trackWidth returns the configured ribbon width
+func (cfg *Config) trackWidth() float64 {
+	if cfg == nil || cfg.TrackWidth <= 0 {
+		return defaultTrackWidth
+	}
+	return cfg.TrackWidth
+}
+
+// trackVector converts a track point to a vector
+func trackVector(p TrackPoint) r3.Vector {
+	return r3.Vector{X: float64(p.X), Y: float64(p.Y), Z: float64(p.Z)}
+}
+
+// trackNormal returns the unit vector to the left of the direction of travel at an index
+func trackNormal(points []TrackPoint, loopLen, i int) r3.Vector {
+	prev := trackVector(points[(i-1+loopLen)%loopLen])
+	next := trackVector(points[(i+1)%loopLen])
+	dir := next.Sub(prev)
+	normal := r3.Vector{X: -dir.Y, Y: dir.X}
+	if normal.Norm() == 0 {
+		return r3.Vector{}
+	}
+	return normal.Normalize()
+}
+
+// indexColor returns the colour of a track index: its mini-sector's timing status if it has one,
+// otherwise a gradient from blue (0) to red (N-1)
+func indexColor(i, lastIdx int, timing *sectorTiming) color.NRGBA {
+	if timing != nil {
+		if statusColor, ok := statusColors[timing.statusAt(i)]; ok {
+			return statusColor
+		}
+	}
+	if lastIdx <= 0 {
+		return color.NRGBA{B: 255, A: 255}
+	}
+	r := uint8((i * 255) / lastIdx)
+	b := uint8(255 - (i * 255 / lastIdx))
+	return color.NRGBA{R: r, G: 0, B: b, A: 255}
+}
+
+// drawTrackPoints draws every point of the track into a point cloud
+func (r *motionToolsRenderer) drawTrackPoints(scene TrackScene, transform sceneTransform) ([]string, error) {
+	pc := pointcloud.NewBasicEmpty()
+	for i, point := range scene.Track.Points {
+		if err := pc.Set(transform.apply(trackVector(point)), pointcloud.NewColoredData(scene.Colors[i])); err != nil {
+			return nil, err
+		}
+	}
+
+	return []string{"reference"}, vizClient.DrawPointCloud("reference", pc, nil)
+}
+
+// drawTrackLine draws the track as a closed polyline, one segment per mini-sector so each can be coloured
+func (r *motionToolsRenderer) drawTrackLine(scene TrackScene, transform sceneTransform) ([]string, error) {
+	track := scene.Track
+	loopLen := len(track.Points)
+	_, miniStarts := track.sectorLayout(loopLen)
+
+	labels := make([]string, 0, len(miniStarts))
+	for m, start := range miniStarts {
+		// The last mini-sector wraps around past the start/finish line to the first one
+		end := loopLen + miniStarts[0]
+		if m+1 < len(miniStarts) {
+			end = miniStarts[m+1]
+		}
+
+		poses := make([]spatialmath.Pose, 0, end-start+1)
+		for i := start; i <= end; i++ {
+			poses = append(poses, spatialmath.NewPoseFromPoint(transform.apply(trackVector(track.Points[i%loopLen]))))
+		}
+
+		c := scene.Colors[((start+end)/2)%loopLen]
+		lineColor := [3]uint8{c.R, c.G, c.B}
+		label := fmt.Sprintf("reference-%d", m)
+		if err := vizClient.DrawLine(label, poses, &lineColor, nil); err != nil {
+			return nil, err
+		}
+		labels = append(labels, label)
+	}
+	return labels, nil
+}
+
+// drawTrackRibbon draws the track as a filled band of the configured width
+func (r *motionToolsRenderer) drawTrackRibbon(scene TrackScene, transform sceneTransform) ([]string, error) {
+	loopLen := len(scene.Track.Points)
+	points := scene.Track.closedPoints()
+	width := r.cfg.trackWidth()
+
+	// Fill the band with points roughly a third of the width apart, both along and across the track
+	spacing := width / 3
+	across := int(math.Ceil(width/spacing)) + 1
+
+	pc := pointcloud.NewBasicEmpty()
+	for i := 0; i < loopLen; i++ {
+		a, b := trackVector(points[i]), trackVector(points[i+1])
+		na, nb := trackNormal(points, loopLen, i), trackNormal(points, loopLen, i+1)
+		c := pointcloud.NewColoredData(scene.Colors[i])
+
+		steps := max(1, int(math.Ceil(b.Sub(a).Norm()/spacing)))
+		for step := 0; step < steps; step++ {
+			t := float64(step) / float64(steps)
+			center := a.Add(b.Sub(a).Mul(t))
+			normal := na.Add(nb.Sub(na).Mul(t))
+			for k := 0; k < across; k++ {
+				offset := (float64(k)/float64(across-1) - 0.5) * width
+				if err := pc.Set(transform.apply(center.Add(normal.Mul(offset))), c); err != nil {
+					return nil, err
+				}
+			}
+		}
+	}
+
+	return []string{"reference"}, vizClient.DrawPointCloud("reference", pc, nil)
+}
+
+// drawTrackMarker draws a line across the track at an index
+func drawTrackMarker(label string, track *ReferenceTrack, index int, width float64, markerColor [3]uint8, transform sceneTransform) error {
+	loopLen := len(track.Points)
+	center := trackVector(track.Points[index%loopLen])
+	half := trackNormal(track.Points, loopLen, index%loopLen).Mul(width * markerWidthRatio / 2)
+
+	poses := []spatialmath.Pose{
+		spatialmath.NewPoseFromPoint(transform.apply(center.Sub(half))),
+		spatialmath.NewPoseFromPoint(transform.apply(center.Add(half))),
+	}
+	return vizClient.DrawLine(label, poses, &markerColor, nil)
+}
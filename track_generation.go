@@ -0,0 +1,284 @@
+package f1viz
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"sort"
+
+	"github.com/golang/geo/r3"
+)
+
+const (
+	// defaultGenerationLaps is how many clean laps per driver are averaged when laps are not listed explicitly
+	defaultGenerationLaps = 3
+	// defaultSmoothing is the half-width, in points, of the moving average applied to the averaged centreline
+	defaultSmoothing = 2
+	// cleanLapRatio is how much slower than a driver's fastest lap a lap may be and still count as clean (107%)
+	cleanLapRatio = 1.07
+	// minAlignmentResolution is the fewest points laps are resampled to before they are aligned and averaged
+	minAlignmentResolution = 500
+)
+
+// TrackSourceLap identifies a lap that contributed to a generated reference track
+type TrackSourceLap struct {
+	DriverNumber int `json:"driver_number"`
+	LapNumber    int `json:"lap_number"`
+}
+
+// TrackQuality describes how well the laps a reference track was generated from agree with each other
+type TrackQuality struct {
+	Laps          []TrackSourceLap `json:"laps"`
+	ClosureError  float64          `json:"closure_error"`  // Largest gap between the first and last location of a lap
+	MaxDeviation  float64          `json:"max_deviation"`  // Largest distance from any aligned lap point to the centreline
+	MeanDeviation float64          `json:"mean_deviation"` // Average distance from aligned lap points to the centreline
+}
+
+// trackGenerationOptions selects the laps a reference track is generated from
+type trackGenerationOptions struct {
+	driverNumbers []int
+	lapNumbers    []int // Specific laps used for every driver; when empty, the fastest clean laps are used
+	laps          int   // Number of clean laps per driver when lapNumbers is empty
+	smoothing     int   // Moving average half-width in points
+}
+
+// cleanLaps returns up to n of a driver's fastest laps, skipping the opening lap, pit-out laps, in-laps
+// and any lap more than 7% slower than the driver's fastest
+func cleanLaps(laps []Lap, n int) []int {
+	pitOut := make(map[int]bool)
+	for _, lap := range laps {
+		if lap.IsPitOutLap {
+			pitOut[lap.LapNumber] = true
+		}
+	}
+
+	var candidates []Lap
+	fastest := math.MaxFloat64
+	for _, lap := range laps {
+		if lap.LapNumber <= 1 || lap.IsPitOutLap || pitOut[lap.LapNumber+1] {
+			continue
+		}
+		if lap.DateStart == "" || lap.LapDuration == nil {
+			continue
+		}
+		candidates = append(candidates, lap)
+		fastest = math.Min(fastest, *lap.LapDuration)
+	}
+
+	sort.Slice(candidates, func(i, j int) bool {
+		return *candidates[i].LapDuration < *candidates[j].LapDuration
+	})
+
+	lapNumbers := make([]int, 0, n)
+	for _, lap := range candidates {
+		if len(lapNumbers) == n || *lap.LapDuration > fastest*cleanLapRatio {
+			break
+		}
+		lapNumbers = append(lapNumbers, lap.LapNumber)
+	}
+	return lapNumbers
+}
+
+// buildReferenceTrack generates and validates a reference track by averaging laps of OpenF1 location data
+func (s *vizF1viz) buildReferenceTrack(ctx context.Context, session Session, opts trackGenerationOptions) (*ReferenceTrack, error) {
+	if opts.laps <= 0 {
+		opts.laps = defaultGenerationLaps
+	}
+
+	var loops [][]TrackPoint
+	var sources []TrackSourceLap
+	for _, driverNumber := range opts.driverNumbers {
+		laps, err := fetchLaps(ctx, session.SessionKey, driverNumber)
+		if err != nil {
+			return nil, fmt.Errorf("failed to fetch laps for driver %d: %w", driverNumber, err)
+		}
+
+		lapNumbers := opts.lapNumbers
+		if len(lapNumbers) == 0 {
+			lapNumbers = cleanLaps(laps, opts.laps)
+			if len(lapNumbers) == 0 {
+				s.logger.Warnf("No clean laps found for driver %d, skipping", driverNumber)
+				continue
+			}
+		}
+
+		for _, lapNumber := range lapNumbers {
+			lapStart, lapEnd, err := lapWindow(laps, lapNumber)
+			if err != nil {
+				return nil, fmt.Errorf("driver %d: %w", driverNumber, err)
+			}
+			locations, err := s.fetchLocationData(ctx, session.SessionKey, driverNumber, lapStart, lapEnd)
+			if err != nil {
+				return nil, fmt.Errorf("failed to fetch location data for driver %d lap %d: %w", driverNumber, lapNumber, err)
+			}
+			if len(locations) < minTrackResolution {
+				s.logger.Warnf("Only %d locations for driver %d lap %d, skipping", len(locations), driverNumber, lapNumber)
+				continue
+			}
+
+			loop := make([]TrackPoint, len(locations))
+			for i, loc := range locations {
+				loop[i] = TrackPoint{X: loc.X, Y: loc.Y, Z: loc.Z}
+			}
+			loops = append(loops, loop)
+			sources = append(sources, TrackSourceLap{DriverNumber: driverNumber, LapNumber: lapNumber})
+		}
+	}
+	if len(loops) == 0 {
+		return nil, fmt.Errorf("no usable laps for drivers %v", opts.driverNumbers)
+	}
+
+	track, err := generateAveragedTrack(loops, s.trackLibrary.resolution, opts.smoothing)
+	if err != nil {
+		return nil, fmt.Errorf("failed to generate reference track: %w", err)
+	}
+	track.CircuitKey = session.CircuitKey
+	track.Quality.Laps = sources
+	if err := validateReferenceTrack(track); err != nil {
+		return nil, fmt.Errorf("generated reference track is invalid: %w", err)
+	}
+
+	s.logger.Infof("Generated reference track for circuit %d from %d laps of session %d (closure error %.0f, max deviation %.0f)",
+		session.CircuitKey, len(sources), session.SessionKey, track.Quality.ClosureError, track.Quality.MaxDeviation)
+	return track, nil
+}
+
+// generateAveragedTrack builds a reference track from one or more laps. Each lap is resampled into a closed loop,
+// aligned with the first lap, and the aligned laps are averaged and smoothed into a centreline with resolution points.
+func generateAveragedTrack(laps [][]TrackPoint, resolution, smoothing int) (*ReferenceTrack, error) {
+	if resolution < minTrackResolution {
+		return nil, fmt.Errorf("reference track resolution must be at least %d, got %d", minTrackResolution, resolution)
+	}
+	if len(laps) == 0 {
+		return nil, fmt.Errorf("need at least one lap to generate a reference track")
+	}
+
+	m := max(resolution*2, minAlignmentResolution)
+	quality := &TrackQuality{}
+
+	loops := make([][]r3.Vector, 0, len(laps))
+	for i, lap := range laps {
+		first, last := lap[0], lap[len(lap)-1]
+		quality.ClosureError = math.Max(quality.ClosureError, distance2D(first.X, first.Y, last.X, last.Y))
+
+		loop, err := resampleLoop(lap, m)
+		if err != nil {
+			return nil, fmt.Errorf("lap %d: %w", i, err)
+		}
+		loops = append(loops, loop)
+	}
+
+	// Align every lap with the first, then refine the alignment against the averaged centreline
+	aligned := make([][]r3.Vector, len(loops))
+	for i, loop := range loops {
+		aligned[i] = alignLoop(loop, loops[0])
+	}
+	centreline := averageLoops(aligned)
+	for i, loop := range loops {
+		aligned[i] = alignLoop(loop, centreline)
+	}
+	centreline = smoothLoop(averageLoops(aligned), smoothing)
+
+	// Measure how far the laps stray from the centreline
+	var totalDeviation float64
+	for _, loop := range aligned {
+		for i, p := range loop {
+			deviation := math.Hypot(p.X-centreline[i].X, p.Y-centreline[i].Y)
+			totalDeviation += deviation
+			quality.MaxDeviation = math.Max(quality.MaxDeviation, deviation)
+		}
+	}
+	quality.MeanDeviation = totalDeviation / float64(len(aligned)*m)
+
+	// Resample the loop to the requested resolution
+	path := make([]TrackPoint, 0, m)
+	for _, p := range centreline {
+		path = append(path, TrackPoint{X: int(math.Round(p.X)), Y: int(math.Round(p.Y)), Z: int(math.Round(p.Z))})
+	}
+	points, err := resampleLoopPath(path, resolution)
+	if err != nil {
+		return nil, err
+	}
+
+	return &ReferenceTrack{
+		Resolution: resolution,
+		StartPoint: points[0],
+		Points:     points,
+		Quality:    quality,
+	}, nil
+}
+
+// resampleLoop closes a lap back on its first point and returns m points evenly spaced around it
+func resampleLoop(lap []TrackPoint, m int) ([]r3.Vector, error) {
+	points, err := resampleLoopPath(lap, m)
+	if err != nil {
+		return nil, err
+	}
+	loop := make([]r3.Vector, m)
+	for i := range loop {
+		loop[i] = r3.Vector{X: float64(points[i].X), Y: float64(points[i].Y), Z: float64(points[i].Z)}
+	}
+	return loop, nil
+}
+
+// alignLoop reorders a loop so that each index lines up with the same index of ref. The loop is first rotated
+// so its start is nearest ref's start, then each point is replaced by the nearest point within a small window,
+// which absorbs differences in racing line and lap length.
+func alignLoop(loop, ref []r3.Vector) []r3.Vector {
+	m := len(loop)
+	dist2D := func(a, b r3.Vector) float64 {
+		return math.Hypot(a.X-b.X, a.Y-b.Y)
+	}
+
+	offset := 0
+	best := math.MaxFloat64
+	for k, p := range loop {
+		if d := dist2D(p, ref[0]); d < best {
+			best, offset = d, k
+		}
+	}
+
+	window := max(2, m/50)
+	aligned := make([]r3.Vector, m)
+	for i := range aligned {
+		best = math.MaxFloat64
+		for d := -window; d <= window; d++ {
+			p := loop[((offset+i+d)%m+m)%m]
+			if dist := dist2D(p, ref[i]); dist < best {
+				best, aligned[i] = dist, p
+			}
+		}
+	}
+	return aligned
+}
+
+// averageLoops returns the point-wise mean of aligned loops
+func averageLoops(loops [][]r3.Vector) []r3.Vector {
+	avg := make([]r3.Vector, len(loops[0]))
+	for _, loop := range loops {
+		for i, p := range loop {
+			avg[i] = avg[i].Add(p)
+		}
+	}
+	for i := range avg {
+		avg[i] = avg[i].Mul(1 / float64(len(loops)))
+	}
+	return avg
+}
+
+// smoothLoop applies a circular moving average with the given half-width
+func smoothLoop(loop []r3.Vector, halfWidth int) []r3.Vector {
+	if halfWidth <= 0 {
+		return loop
+	}
+	m := len(loop)
+	smoothed := make([]r3.Vector, m)
+	for i := range loop {
+		var sum r3.Vector
+		for d := -halfWidth; d <= halfWidth; d++ {
+			sum = sum.Add(loop[((i+d)%m+m)%m])
+		}
+		smoothed[i] = sum.Mul(1 / float64(2*halfWidth+1))
+	}
+	return smoothed
+}
@@ -0,0 +1,194 @@
+package f1viz
+
+import (
+	"math"
+
+	"github.com/golang/geo/r3"
+)
+
+const (
+	// unknownTrackIndex marks a location that could not be mapped onto a reference track
+	unknownTrackIndex = -1
+	// matchRadiusSegments is the search radius around a location, in average track segment lengths
+	matchRadiusSegments = 3.0
+	// minMatchRadius is the smallest search radius in OpenF1 units, for very high resolution tracks
+	minMatchRadius = 500.0
+	// elevationWeight scales the Z difference to a candidate point, so the upper and lower levels of a
+	// crossover such as Suzuka's bridge are told apart
+	elevationWeight = 3.0
+	// headingPenaltySegments is added, in segment lengths, when a candidate runs against the car's heading
+	headingPenaltySegments = 2.0
+	// jumpPenaltySegments is added, in segment lengths, when a candidate is not a plausible step forward
+	// from the driver's previous index
+	jumpPenaltySegments = 10.0
+	// maxBackwardSteps is how many indices a driver may slip backwards without counting as a jump
+	maxBackwardSteps = 2
+)
+
+// trackMatcher maps locations onto a reference track. Points are bucketed into a grid so that only
+// nearby points are considered, and each driver's previous index and heading are used to prefer
+// forward progress where parts of the track run close together or cross.
+type trackMatcher struct {
+	track      *ReferenceTrack
+	loopLen    int     // Number of points around the track
+	segment    float64 // Average distance between consecutive points
+	radius     float64 // Search radius, also the grid cell size
+	maxForward int     // Largest forward step treated as continuous progress
+	cells      map[[2]int][]int
+	pit        *pitMatcher // Nil when the track has no pit lane
+}
+
+// driverTrackState is the matching state carried between a driver's locations
+type driverTrackState struct {
+	index    int
+	previous Location
+	hasPrev  bool
+
+	inPit       bool
+	pitPosition float64 // Furthest position reached along the pit lane, in pit lane points
+	pitProgress float64 // 0 at the pit entry to 1 at the pit exit
+}
+
+// newTrackMatcher builds a matcher for a reference track
+func newTrackMatcher(track *ReferenceTrack) *trackMatcher {
+	m := &trackMatcher{track: track, loopLen: len(track.Points), pit: newPitMatcher(track)}
+
+	var total float64
+	for i := 0; i < m.loopLen; i++ {
+		a, b := track.Points[i], track.Points[(i+1)%m.loopLen]
+		total += distance2D(a.X, a.Y, b.X, b.Y)
+	}
+	m.segment = total / float64(m.loopLen)
+	m.radius = math.Max(m.segment*matchRadiusSegments, minMatchRadius)
+	m.maxForward = max(4, m.loopLen/10)
+
+	m.cells = make(map[[2]int][]int)
+	for i := 0; i < m.loopLen; i++ {
+		cell := m.cellOf(track.Points[i].X, track.Points[i].Y)
+		m.cells[cell] = append(m.cells[cell], i)
+	}
+	return m
+}
+
+// cellOf returns the grid cell containing a point
+func (m *trackMatcher) cellOf(x, y int) [2]int {
+	return [2]int{int(math.Floor(float64(x) / m.radius)), int(math.Floor(float64(y) / m.radius))}
+}
+
+// candidates returns the indices of points in the cells around a location, or every point if none are nearby
+func (m *trackMatcher) candidates(location Location) []int {
+	center := m.cellOf(location.X, location.Y)
+	var indices []int
+	for dx := -1; dx <= 1; dx++ {
+		for dy := -1; dy <= 1; dy++ {
+			indices = append(indices, m.cells[[2]int{center[0] + dx, center[1] + dy}]...)
+		}
+	}
+	if len(indices) > 0 {
+		return indices
+	}
+
+	// Off the map (e.g. a data glitch) - fall back to scanning the whole track
+	indices = make([]int, m.loopLen)
+	for i := range indices {
+		indices[i] = i
+	}
+	return indices
+}
+
+// direction returns the direction of travel along the track at an index
+func (m *trackMatcher) direction(i int) r3.Vector {
+	prev := m.track.Points[(i-1+m.loopLen)%m.loopLen]
+	next := m.track.Points[(i+1)%m.loopLen]
+	return r3.Vector{X: float64(next.X - prev.X), Y: float64(next.Y - prev.Y)}
+}
+
+// match maps a location to an index 0 to N-1 and updates the driver's matching state.
+// Cars in the pit lane are mapped onto the main track in proportion to their pit lane progress.
+func (m *trackMatcher) match(location Location, state *driverTrackState) int {
+	if m.pit != nil && m.matchPit(location, state) {
+		index := m.pit.trackIndex(state.pitProgress)
+		state.index = index
+		state.previous = location
+		state.hasPrev = true
+		return index
+	}
+
+	var heading r3.Vector
+	if state.hasPrev {
+		heading = r3.Vector{X: float64(location.X - state.previous.X), Y: float64(location.Y - state.previous.Y)}
+	}
+	moving := heading.Norm() > m.segment*0.1
+
+	bestIdx := unknownTrackIndex
+	bestScore := math.MaxFloat64
+	for _, i := range m.candidates(location) {
+		p := m.track.Points[i]
+		score := distance2D(location.X, location.Y, p.X, p.Y)
+		score += math.Abs(float64(location.Z-p.Z)) * elevationWeight
+
+		if moving {
+			// Penalise points whose direction of travel opposes the car's
+			if dir := m.direction(i); dir.Norm() > 0 {
+				if cos := heading.Dot(dir) / (heading.Norm() * dir.Norm()); cos < 0 {
+					score += -cos * headingPenaltySegments * m.segment
+				}
+			}
+		}
+
+		if state.hasPrev {
+			// Prefer small steps forward from the previous index
+			forward := (i - state.index + m.loopLen) % m.loopLen
+			backward := m.loopLen - forward
+			switch {
+			case forward <= m.maxForward:
+			case backward <= maxBackwardSteps:
+				score += float64(backward) * m.segment * 0.5
+			default:
+				score += jumpPenaltySegments * m.segment
+			}
+		}
+
+		if score < bestScore {
+			bestScore = score
+			bestIdx = i
+		}
+	}
+
+	state.index = bestIdx
+	state.previous = location
+	state.hasPrev = true
+	return bestIdx
+}
+
+// matchPit updates a driver's pit lane state and returns true if the location is in the pit lane.
+// A car only enters the pit lane when approaching the pit entry, so cars on a parallel straight are not
+// mistaken for it.
+func (m *trackMatcher) matchPit(location Location, state *driverTrackState) bool {
+	pitPos, pitDist := m.pit.nearest(location)
+	mainDist, _ := distanceToTrack(float64(location.X), float64(location.Y), m.track, m.candidates(location))
+
+	if !state.inPit {
+		if pitDist >= mainDist || mainDist <= pitLaneMinOffset {
+			return false
+		}
+		if state.hasPrev {
+			toEntry := (m.pit.pit.EntryIndex - state.index + m.loopLen) % m.loopLen
+			fromEntry := (state.index - m.pit.pit.EntryIndex + m.loopLen) % m.loopLen
+			if toEntry > pitEntryWindowSegments && fromEntry > pitEntryWindowSegments {
+				return false
+			}
+		}
+		state.inPit = true
+		state.pitPosition = 0
+	} else if pitDist > mainDist {
+		// Back on the main track; carry on from the pit exit
+		state.inPit = false
+		state.index = m.pit.pit.ExitIndex
+		return false
+	}
+
+	state.pitPosition = max(state.pitPosition, pitPos)
+	state.pitProgress = state.pitPosition / float64(len(m.pit.pit.Points)-1)
+	return true
+}
@@ -0,0 +1,64 @@
+package f1viz
+
+import "testing"
+
+// hairpinTrack is a loop of two parallel straights 600 apart, out along y=0 (indices 0-9) and back
+// along y=600 (indices 10-19), close enough that a car between them could be on either
+func hairpinTrack() *ReferenceTrack {
+	track := &ReferenceTrack{}
+	for x := 0; x <= 9000; x += 1000 {
+		track.Points = append(track.Points, TrackPoint{X: x})
+	}
+	for x := 9000; x >= 0; x -= 1000 {
+		track.Points = append(track.Points, TrackPoint{X: x, Y: 600})
+	}
+	track.Resolution = len(track.Points)
+	return track
+}
+
+func TestTrackMatcherMatch(t *testing.T) {
+	m := newTrackMatcher(hairpinTrack())
+
+	tests := []struct {
+		name     string
+		state    driverTrackState
+		location Location
+		want     int
+	}{
+		{
+			name:     "nearest point without history",
+			location: Location{X: 5000, Y: 250},
+			want:     5,
+		},
+		{
+			name:     "continuity keeps a car on the outbound straight",
+			state:    driverTrackState{index: 4, previous: Location{X: 5000, Y: 350}, hasPrev: true},
+			location: Location{X: 5000, Y: 350},
+			want:     5,
+		},
+		{
+			name:     "continuity keeps a car on the return straight",
+			state:    driverTrackState{index: 13, previous: Location{X: 5000, Y: 250}, hasPrev: true},
+			location: Location{X: 5000, Y: 250},
+			want:     14,
+		},
+		{
+			name:     "small slip backwards stays on the same straight",
+			state:    driverTrackState{index: 6, previous: Location{X: 5000, Y: 350}, hasPrev: true},
+			location: Location{X: 5000, Y: 350},
+			want:     5,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			state := tt.state
+			if got := m.match(tt.location, &state); got != tt.want {
+				t.Errorf("match = %d, want %d", got, tt.want)
+			}
+			if state.index != tt.want || !state.hasPrev || state.previous != tt.location {
+				t.Errorf("state not updated: %+v", state)
+			}
+		})
+	}
+}
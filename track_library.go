@@ -0,0 +1,151 @@
+package f1viz
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+)
+
+const (
+	// defaultReferenceTrackDir is the bundled reference track library, relative to the module root
+	defaultReferenceTrackDir = "reference_tracks"
+	// onDemandDrivers is how many of the started drivers' laps are averaged when a missing
+	// reference track is generated on demand
+	onDemandDrivers = 3
+)
+
+// trackLibrary is a set of directories holding reference tracks named <circuit_key>.json
+type trackLibrary struct {
+	dirs       []string // Searched in order
+	cacheDir   string   // Where generated tracks are saved
+	resolution int      // Number of points tracks are loaded and generated with
+}
+
+// newTrackLibrary builds the library for a config. Relative directories are resolved against
+// VIAM_MODULE_ROOT so the library does not depend on the module's working directory.
+// Generated tracks are cached in the configured directory, or in VIAM_MODULE_DATA when none is configured.
+func newTrackLibrary(cfg *Config) *trackLibrary {
+	moduleRoot := os.Getenv("VIAM_MODULE_ROOT")
+	resolve := func(dir string) string {
+		if filepath.IsAbs(dir) || moduleRoot == "" {
+			return dir
+		}
+		return filepath.Join(moduleRoot, dir)
+	}
+
+	resolution := defaultTrackResolution
+	if cfg != nil && cfg.TrackResolution > 0 {
+		resolution = cfg.TrackResolution
+	}
+
+	if cfg != nil && cfg.ReferenceTrackDir != "" {
+		dir := resolve(cfg.ReferenceTrackDir)
+		return &trackLibrary{dirs: []string{dir}, cacheDir: dir, resolution: resolution}
+	}
+
+	lib := &trackLibrary{resolution: resolution}
+	if dataDir := os.Getenv("VIAM_MODULE_DATA"); dataDir != "" {
+		lib.cacheDir = filepath.Join(dataDir, defaultReferenceTrackDir)
+		lib.dirs = append(lib.dirs, lib.cacheDir)
+	}
+	bundled := resolve(defaultReferenceTrackDir)
+	lib.dirs = append(lib.dirs, bundled)
+	if lib.cacheDir == "" {
+		lib.cacheDir = bundled
+	}
+	return lib
+}
+
+// trackFilename returns the file name of a circuit's reference track
+func trackFilename(circuitKey int) string {
+	return strconv.Itoa(circuitKey) + ".json"
+}
+
+// cachePath returns where a generated track for a circuit is saved
+func (lib *trackLibrary) cachePath(circuitKey int) string {
+	return filepath.Join(lib.cacheDir, trackFilename(circuitKey))
+}
+
+// load returns the reference track for a circuit from the first directory that has one, resampled to
+// the library's resolution. Returns an error wrapping os.ErrNotExist if no directory has the circuit.
+func (lib *trackLibrary) load(circuitKey int) (*ReferenceTrack, string, error) {
+	for _, dir := range lib.dirs {
+		path := filepath.Join(dir, trackFilename(circuitKey))
+		track, err := loadReferenceTrack(path)
+		if errors.Is(err, os.ErrNotExist) {
+			continue
+		}
+		if err != nil {
+			return nil, path, fmt.Errorf("failed to load reference track %s: %w", path, err)
+		}
+		if track.CircuitKey == 0 {
+			track.CircuitKey = circuitKey
+		}
+		if track.Resolution != lib.resolution {
+			track, err = resampleReferenceTrack(track, lib.resolution)
+			if err != nil {
+				return nil, path, fmt.Errorf("failed to resample reference track %s: %w", path, err)
+			}
+		}
+		return track, path, nil
+	}
+	return nil, "", fmt.Errorf("no reference track for circuit %d in %v: %w", circuitKey, lib.dirs, os.ErrNotExist)
+}
+
+// save writes a reference track into the cache directory
+func (lib *trackLibrary) save(track *ReferenceTrack) (string, error) {
+	if err := os.MkdirAll(lib.cacheDir, 0o755); err != nil {
+		return "", fmt.Errorf("failed to create reference track directory: %w", err)
+	}
+	path := lib.cachePath(track.CircuitKey)
+	if err := saveReferenceTrack(track, path); err != nil {
+		return "", err
+	}
+	return path, nil
+}
+
+// selectReferenceTrack makes the reference track for a session's circuit active. If the library does
+// not have one, it is generated from the clean laps of the first few driverNumbers and cached.
+func (s *vizF1viz) selectReferenceTrack(ctx context.Context, session Session, driverNumbers []int) error {
+	if track := s.currentReferenceTrack(); track != nil && track.CircuitKey == session.CircuitKey {
+		return nil
+	}
+
+	track, path, err := s.trackLibrary.load(session.CircuitKey)
+	if err == nil {
+		s.logger.Infof("Using reference track for circuit %d from %s", session.CircuitKey, path)
+		s.setReferenceTrack(track)
+		return nil
+	}
+	if !errors.Is(err, os.ErrNotExist) {
+		return err
+	}
+
+	if len(driverNumbers) > onDemandDrivers {
+		driverNumbers = driverNumbers[:onDemandDrivers]
+	}
+	s.logger.Infof("No reference track for circuit %d, generating one from drivers %v", session.CircuitKey, driverNumbers)
+	track, err = s.buildReferenceTrack(ctx, session, trackGenerationOptions{
+		driverNumbers: driverNumbers,
+		laps:          defaultGenerationLaps,
+		smoothing:     defaultSmoothing,
+	})
+	if err != nil {
+		return fmt.Errorf("failed to generate reference track for circuit %d: %w", session.CircuitKey, err)
+	}
+	if pit, err := s.buildPitLane(ctx, session, driverNumbers, track); err != nil {
+		s.logger.Infof("Reference track for circuit %d has no pit lane: %v", session.CircuitKey, err)
+	} else {
+		track.PitLane = pit
+	}
+	if path, err = s.trackLibrary.save(track); err != nil {
+		s.logger.Warnf("Failed to cache reference track for circuit %d: %v", session.CircuitKey, err)
+	} else {
+		s.logger.Infof("Cached reference track for circuit %d at %s", session.CircuitKey, path)
+	}
+	s.setReferenceTrack(track)
+	return nil
+}
@@ -0,0 +1,239 @@
+package f1viz
+
+import (
+	"fmt"
+	"math"
+	"time"
+)
+
+const (
+	fadeLinear      = "linear"
+	fadeExponential = "exponential"
+	fadeNone        = "none"
+
+	// defaultTrailLength is the number of samples in a trail when none is configured
+	defaultTrailLength = 5
+	// exponentialFadeRate controls how quickly exponential trails fade; the oldest point keeps e^-rate of its colour
+	exponentialFadeRate = 4.0
+)
+
+// TrailConfig controls each driver's trail
+type TrailConfig struct {
+	// Number of samples in each trail. Defaults to 5.
+	Length int `json:"length,omitempty"`
+	// Keep this many seconds of playback time in each trail instead of a fixed number of samples
+	Seconds float64 `json:"seconds,omitempty"`
+	// How trails fade with age when trail_color is "fade": "linear" (default), "exponential" or "none"
+	Fade string `json:"fade,omitempty"`
+	// Also draw each driver's last complete lap as a faint ghost
+	Ghost bool `json:"ghost,omitempty"`
+}
+
+// validate checks the trail settings
+func (tc *TrailConfig) validate(path string) error {
+	if tc.Length < 0 {
+		return fmt.Errorf("%s: trail.length must not be negative", path)
+	}
+	if tc.Seconds < 0 {
+		return fmt.Errorf("%s: trail.seconds must not be negative", path)
+	}
+	if err := validateFade(tc.Fade); tc.Fade != "" && err != nil {
+		return fmt.Errorf("%s: trail.%w", path, err)
+	}
+	return nil
+}
+
+// validateFade checks a fade curve
+func validateFade(fade string) error {
+	switch fade {
+	case fadeLinear, fadeExponential, fadeNone:
+		return nil
+	default:
+		return fmt.Errorf("fade must be %q, %q or %q, got %q", fadeLinear, fadeExponential, fadeNone, fade)
+	}
+}
+
+// trailSettings are the resolved trail settings for a driver
+type trailSettings struct {
+	Length  int     `json:"length"`
+	Seconds float64 `json:"seconds"`
+	Fade    string  `json:"fade"`
+	Ghost   bool    `json:"ghost"`
+}
+
+// trailDefaults returns the configured trail settings with defaults filled in
+func (cfg *Config) trailDefaults() trailSettings {
+	settings := trailSettings{Length: defaultTrailLength, Fade: fadeLinear}
+	if cfg == nil || cfg.Trail == nil {
+		return settings
+	}
+	if cfg.Trail.Length > 0 {
+		settings.Length = cfg.Trail.Length
+	}
+	if cfg.Trail.Fade != "" {
+		settings.Fade = cfg.Trail.Fade
+	}
+	settings.Seconds = cfg.Trail.Seconds
+	settings.Ghost = cfg.Trail.Ghost
+	return settings
+}
+
+// fadeFactor returns how much of a trail point's colour is kept, from 0 (black) to 1, for the point at
+// index i of a trail of n points, oldest first
+func fadeFactor(fade string, i, n int) float64 {
+	if n <= 1 || fade == fadeNone {
+		return 1
+	}
+	age := 1 - float64(i)/float64(n-1) // 0 for the newest point, 1 for the oldest
+	switch fade {
+	case fadeExponential:
+		return math.Exp(-exponentialFadeRate * age)
+	default:
+		return 1 - age
+	}
+}
+
+// trailSettingsFor returns the trail settings for a driver: their override, else the override for
+// every driver, else the configured settings
+func (s *vizF1viz) trailSettingsFor(driverNumber int) trailSettings {
+	s.stateMu.RLock()
+	defer s.stateMu.RUnlock()
+	return s.trailSettingsForLocked(driverNumber)
+}
+
+func (s *vizF1viz) trailSettingsForLocked(driverNumber int) trailSettings {
+	if settings, ok := s.trailOverrides[driverNumber]; ok {
+		return settings
+	}
+	if s.trailDefault != nil {
+		return *s.trailDefault
+	}
+	return s.cfg.trailDefaults()
+}
+
+// setTrailRequest holds the arguments of a set_trail command. Omitted settings are left as they are.
+type setTrailRequest struct {
+	DriverNumber int      `json:"driver_number"` // Driver to override; 0 changes every driver without an override
+	Length       *int     `json:"length"`
+	Seconds      *float64 `json:"seconds"`
+	Fade         *string  `json:"fade"`
+	Ghost        *bool    `json:"ghost"`
+	Reset        bool     `json:"reset"` // Go back to the configured settings, for the driver or for everyone
+}
+
+// setTrailCommand handles the set_trail command, changing trail settings for one driver or all of them
+func (s *vizF1viz) setTrailCommand(cmdValue interface{}) (map[string]interface{}, error) {
+	var req setTrailRequest
+	if err := decodeCommandArgs(cmdValue, &req); err != nil {
+		return nil, fmt.Errorf("set_trail: %w", err)
+	}
+	if req.Length != nil && *req.Length < 1 {
+		return nil, fmt.Errorf("set_trail: length must be at least 1, got %d", *req.Length)
+	}
+	if req.Seconds != nil && *req.Seconds < 0 {
+		return nil, fmt.Errorf("set_trail: seconds must not be negative")
+	}
+	if req.Fade != nil {
+		if err := validateFade(*req.Fade); err != nil {
+			return nil, fmt.Errorf("set_trail: %w", err)
+		}
+	}
+
+	s.stateMu.Lock()
+	defer s.stateMu.Unlock()
+	if s.trailOverrides == nil {
+		s.trailOverrides = make(map[int]trailSettings)
+	}
+
+	switch {
+	case req.Reset && req.DriverNumber != 0:
+		delete(s.trailOverrides, req.DriverNumber)
+	case req.Reset:
+		s.trailDefault = nil
+		s.trailOverrides = make(map[int]trailSettings)
+	default:
+		settings := s.trailSettingsForLocked(req.DriverNumber)
+		if req.Length != nil {
+			settings.Length = *req.Length
+		}
+		if req.Seconds != nil {
+			settings.Seconds = *req.Seconds
+		}
+		if req.Fade != nil {
+			settings.Fade = *req.Fade
+		}
+		if req.Ghost != nil {
+			settings.Ghost = *req.Ghost
+		}
+		if req.DriverNumber != 0 {
+			s.trailOverrides[req.DriverNumber] = settings
+		} else {
+			s.trailDefault = &settings
+		}
+	}
+
+	return toCommandResponse(map[string]interface{}{
+		"status": "success",
+		"trail":  s.trailSettingsForLocked(req.DriverNumber),
+	})
+}
+
+// trailTracker keeps each driver's recent locations and their last complete lap
+type trailTracker struct {
+	histories map[int][]Location
+	laps      map[int][]Location // Locations since each driver's current lap started
+	lapNumber map[int]int
+	ghosts    map[int][]Location // Each driver's last complete lap
+}
+
+func newTrailTracker() *trailTracker {
+	return &trailTracker{
+		histories: make(map[int][]Location),
+		laps:      make(map[int][]Location),
+		lapNumber: make(map[int]int),
+		ghosts:    make(map[int][]Location),
+	}
+}
+
+// update adds a driver's latest location, trimming their trail to its settings. lap is the driver's
+// current lap, 0 if unknown.
+func (t *trailTracker) update(location Location, lap int, settings trailSettings) {
+	driverNumber := location.DriverNumber
+
+	history := append(t.histories[driverNumber], location)
+	if settings.Seconds > 0 {
+		if latest, err := time.Parse(time.RFC3339, location.Date); err == nil {
+			cutoff := latest.Add(-time.Duration(settings.Seconds * float64(time.Second)))
+			start := 0
+			for start < len(history)-1 {
+				date, err := time.Parse(time.RFC3339, history[start].Date)
+				if err != nil || !date.Before(cutoff) {
+					break
+				}
+				start++
+			}
+			history = history[start:]
+		}
+	} else if len(history) > settings.Length {
+		history = history[len(history)-settings.Length:]
+	}
+	t.histories[driverNumber] = history
+
+	// A new lap turns the lap just completed into the ghost, unless it was the run to the start line
+	if previous, ok := t.lapNumber[driverNumber]; ok && lap != previous {
+		if previous >= 1 && lap == previous+1 {
+			t.ghosts[driverNumber] = t.laps[driverNumber]
+		}
+		t.laps[driverNumber] = nil
+	}
+	t.lapNumber[driverNumber] = lap
+	t.laps[driverNumber] = append(t.laps[driverNumber], location)
+}
+
+// remove forgets a driver
+func (t *trailTracker) remove(driverNumber int) {
+	delete(t.histories, driverNumber)
+	delete(t.laps, driverNumber)
+	delete(t.lapNumber, driverNumber)
+	delete(t.ghosts, driverNumber)
+}
@@ -0,0 +1,143 @@
+package f1viz
+
+import (
+	"fmt"
+	"image/color"
+	"math"
+)
+
+const (
+	trailColorFade     = "fade"
+	trailColorSpeed    = "speed"
+	trailColorThrottle = "throttle"
+	trailColorBrake    = "brake"
+	trailColorGear     = "gear"
+
+	// maxScaleSpeed is the speed in km/h at the top of the speed colour scale
+	maxScaleSpeed = 350.0
+	// maxGear is the highest gear
+	maxGear = 8
+)
+
+var (
+	// noTelemetryColor is used for trail points without telemetry
+	noTelemetryColor = color.NRGBA{R: 90, G: 90, B: 90, A: 255}
+	brakeOnColor     = color.NRGBA{R: 255, G: 0, B: 0, A: 255}
+	brakeOffColor    = color.NRGBA{R: 0, G: 160, B: 0, A: 255}
+
+	// scaleStops run from slow/low (blue) to fast/high (red)
+	scaleStops = []color.NRGBA{
+		{R: 0, G: 0, B: 255, A: 255},
+		{R: 0, G: 255, B: 255, A: 255},
+		{R: 0, G: 255, B: 0, A: 255},
+		{R: 255, G: 255, B: 0, A: 255},
+		{R: 255, G: 0, B: 0, A: 255},
+	}
+)
+
+// validateTrailColor checks a trail colour mode
+func validateTrailColor(mode string) error {
+	switch mode {
+	case trailColorFade, trailColorSpeed, trailColorThrottle, trailColorBrake, trailColorGear:
+		return nil
+	default:
+		return fmt.Errorf("trail colour must be %q, %q, %q, %q or %q, got %q",
+			trailColorFade, trailColorSpeed, trailColorThrottle, trailColorBrake, trailColorGear, mode)
+	}
+}
+
+// trailColor returns the configured trail colour mode, defaulting to fading team colours
+func (cfg *Config) trailColor() string {
+	if cfg == nil || cfg.TrailColor == "" {
+		return trailColorFade
+	}
+	return cfg.TrailColor
+}
+
+// currentTrailColor returns the active trail colour mode
+func (s *vizF1viz) currentTrailColor() string {
+	s.stateMu.RLock()
+	defer s.stateMu.RUnlock()
+	if s.trailColorMode == "" {
+		return s.cfg.trailColor()
+	}
+	return s.trailColorMode
+}
+
+// setTrailColorCommand handles the set_trail_color command, whose value is a mode such as "speed"
+func (s *vizF1viz) setTrailColorCommand(cmdValue interface{}) (map[string]interface{}, error) {
+	mode, ok := cmdValue.(string)
+	if !ok {
+		return nil, fmt.Errorf("set_trail_color expects a string, got %T", cmdValue)
+	}
+	if err := validateTrailColor(mode); err != nil {
+		return nil, err
+	}
+
+	s.stateMu.Lock()
+	s.trailColorMode = mode
+	s.stateMu.Unlock()
+	return map[string]interface{}{
+		"status":      "success",
+		"trail_color": mode,
+	}, nil
+}
+
+// trailColors returns the colour of every point of a trail, oldest first. Fade mode fades the driver's
+// colour to black along the trail following the fade curve; the telemetry modes colour each point by its
+// car data.
+func trailColors(mode, fade string, trail []Location, base color.NRGBA) []color.NRGBA {
+	colors := make([]color.NRGBA, len(trail))
+	for i, loc := range trail {
+		if mode == trailColorFade {
+			colors[i] = scaleColor(base, fadeFactor(fade, i, len(trail)))
+			continue
+		}
+		colors[i] = telemetryColor(mode, loc.CarData)
+	}
+	return colors
+}
+
+// telemetryColor maps one telemetry channel of a sample to a colour
+func telemetryColor(mode string, data *CarData) color.NRGBA {
+	if data == nil {
+		return noTelemetryColor
+	}
+	switch mode {
+	case trailColorSpeed:
+		return colorScale(float64(data.Speed) / maxScaleSpeed)
+	case trailColorThrottle:
+		return colorScale(float64(data.Throttle) / 100)
+	case trailColorBrake:
+		if data.Brake > 0 {
+			return brakeOnColor
+		}
+		return brakeOffColor
+	case trailColorGear:
+		return colorScale(float64(data.NGear) / maxGear)
+	default:
+		return noTelemetryColor
+	}
+}
+
+// colorScale returns the colour for a value from 0 (blue) to 1 (red), clamping values outside that range
+func colorScale(v float64) color.NRGBA {
+	v = math.Max(0, math.Min(1, v)) * float64(len(scaleStops)-1)
+	i := min(int(v), len(scaleStops)-2)
+	t := v - float64(i)
+	a, b := scaleStops[i], scaleStops[i+1]
+	lerp := func(x, y uint8) uint8 {
+		return uint8(math.Round(float64(x) + t*(float64(y)-float64(x))))
+	}
+	return color.NRGBA{R: lerp(a.R, b.R), G: lerp(a.G, b.G), B: lerp(a.B, b.B), A: 255}
+}
+
+// scaleColor darkens a colour by a factor from 0 (black) to 1 (unchanged)
+func scaleColor(c color.NRGBA, factor float64) color.NRGBA {
+	return color.NRGBA{
+		R: uint8(float64(c.R) * factor),
+		G: uint8(float64(c.G) * factor),
+		B: uint8(float64(c.B) * factor),
+		A: 255,
+	}
+}
@@ -0,0 +1,93 @@
+package f1viz
+
+import (
+	"fmt"
+	"math"
+
+	"github.com/golang/geo/r3"
+)
+
+// millimetresPerUnit converts OpenF1 location units (roughly decimetres) to millimetres
+const millimetresPerUnit = 100.0
+
+// TransformConfig controls how OpenF1 coordinates are placed in the scene. Steps are applied in order:
+// centre, rotate, scale, exaggerate elevation, convert units.
+type TransformConfig struct {
+	// Translate the reference track's centroid to the origin
+	Center bool `json:"center,omitempty"`
+	// Rotation about the Z axis in degrees, counter-clockwise
+	RotationDegrees float64 `json:"rotation_degrees,omitempty"`
+	// Uniform scale factor. Defaults to 1.
+	Scale float64 `json:"scale,omitempty"`
+	// Extra scale factor applied to elevation only. Defaults to 1.
+	ZExaggeration float64 `json:"z_exaggeration,omitempty"`
+	// Convert OpenF1 units to millimetres, the unit the visualizer expects
+	Millimetres bool `json:"millimetres,omitempty"`
+}
+
+// validate checks the transform's factors
+func (tc *TransformConfig) validate(path string) error {
+	if tc.Scale < 0 {
+		return fmt.Errorf("%s: transform.scale must not be negative", path)
+	}
+	if tc.ZExaggeration < 0 {
+		return fmt.Errorf("%s: transform.z_exaggeration must not be negative", path)
+	}
+	return nil
+}
+
+// sceneTransform maps OpenF1 coordinates to scene coordinates
+type sceneTransform struct {
+	origin   r3.Vector
+	cos, sin float64
+	scale    float64
+	zScale   float64
+}
+
+// identityTransform leaves coordinates unchanged
+var identityTransform = sceneTransform{cos: 1, scale: 1, zScale: 1}
+
+// newSceneTransform builds the transform for a config, centring on the track if asked to
+func newSceneTransform(tc *TransformConfig, track *ReferenceTrack) sceneTransform {
+	if tc == nil {
+		return identityTransform
+	}
+
+	t := identityTransform
+	if tc.Center && track != nil {
+		n := len(track.Points)
+		for _, p := range track.Points {
+			t.origin = t.origin.Add(trackVector(p))
+		}
+		t.origin = t.origin.Mul(1 / float64(n))
+	}
+
+	rad := tc.RotationDegrees * math.Pi / 180
+	t.cos, t.sin = math.Cos(rad), math.Sin(rad)
+	if tc.Scale > 0 {
+		t.scale = tc.Scale
+	}
+	if tc.Millimetres {
+		t.scale *= millimetresPerUnit
+	}
+	t.zScale = t.scale
+	if tc.ZExaggeration > 0 {
+		t.zScale *= tc.ZExaggeration
+	}
+	return t
+}
+
+// heading maps a direction in degrees counter-clockwise from the X axis into the scene
+func (t sceneTransform) heading(degrees float64) float64 {
+	return degrees + math.Atan2(t.sin, t.cos)*180/math.Pi
+}
+
+// apply maps a point into the scene
+func (t sceneTransform) apply(v r3.Vector) r3.Vector {
+	v = v.Sub(t.origin)
+	return r3.Vector{
+		X: (v.X*t.cos - v.Y*t.sin) * t.scale,
+		Y: (v.X*t.sin + v.Y*t.cos) * t.scale,
+		Z: v.Z * t.zScale,
+	}
+}